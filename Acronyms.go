@@ -0,0 +1,303 @@
+package KeyphraseExtraction
+
+import (
+	"strings"
+	"unicode"
+)
+
+// acronymDefinition links a short form such as "SVM" to its long form "Support Vector Machine", and
+// remembers the tokens [dropBegin, dropEnd) that merely repeat the definition, e.g. "SVM)" of
+// "(SVM)". The opening parenthesis is kept to separate the phrases around it.
+type acronymDefinition struct {
+	shortForm string
+	longForm  []string
+	dropBegin int
+	dropEnd   int
+}
+
+// =================================================================================================
+// function normalizeAcronym
+// brief description:
+//   Convert a plural acronym to its singular form, e.g. "CNNs" to "CNN".
+// input:
+//   word: The input word.
+// output:
+//   The singular form of the word if it is a plural acronym; otherwise the original word.
+
+func normalizeAcronym(word string) string {
+	runes := []rune(word)
+	numRunes := len(runes)
+	if numRunes < 3 || runes[numRunes-1] != 's' {
+		return word
+	}
+	numUppercase := 0
+	for _, r := range runes[:numRunes-1] {
+		if unicode.IsUpper(r) {
+			numUppercase++
+		} else if !unicode.IsDigit(r) {
+			return word
+		}
+	}
+	if numUppercase < 2 {
+		return word
+	}
+	return string(runes[:numRunes-1])
+}
+
+// =================================================================================================
+// function isShortFormCandidate
+// brief description:
+//   Check whether a word may be the short form of an acronym definition.
+// input:
+//   word: The input word.
+// output:
+//   true if the word has 2 to 10 chars, starts with a letter or digit and has an uppercase letter.
+
+func isShortFormCandidate(word string) bool {
+	runes := []rune(word)
+	if len(runes) < 2 || len(runes) > 10 {
+		return false
+	}
+	if !unicode.IsLetter(runes[0]) && !unicode.IsDigit(runes[0]) {
+		return false
+	}
+	for _, r := range runes {
+		if unicode.IsUpper(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// =================================================================================================
+// function findBestLongForm
+// brief description:
+//   Find the shortest tail of a sequence of words that matches a short form, using the algorithm of
+//   Schwartz and Hearst.
+// input:
+//   shortForm: The short form, e.g. "SVM".
+//   words: The words preceding (or enclosed with) the short form.
+// output:
+//   The number of words at the end of words that make the long form, or 0 if there is no match.
+// notes:
+//   The reference to the algorithm is:
+//   Schwartz, A. S., & Hearst, M. A. (2003). A simple algorithm for identifying abbreviation
+//   definitions in biomedical text.
+
+func findBestLongForm(shortForm string, words []string) int {
+	// --------------------------------------------------------------------------------------------
+	// step 1: Match the chars of the short form from right to left, requiring the first char to
+	//         start a word
+	sf := []rune(strings.ToLower(shortForm))
+	lf := []rune(strings.ToLower(strings.Join(words, " ")))
+	sIndex := len(sf) - 1
+	lIndex := len(lf) - 1
+	for sIndex >= 0 {
+		currChar := sf[sIndex]
+		if !unicode.IsLetter(currChar) && !unicode.IsDigit(currChar) {
+			sIndex--
+			continue
+		}
+		for lIndex >= 0 && lf[lIndex] != currChar ||
+			sIndex == 0 && lIndex > 0 && (unicode.IsLetter(lf[lIndex-1]) || unicode.IsDigit(lf[lIndex-1])) {
+			lIndex--
+		}
+		if lIndex < 0 {
+			return 0
+		}
+		lIndex--
+		sIndex--
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 2: Extend the match to the beginning of its first word
+	begin := 0
+	for i := lIndex; i >= 0; i-- {
+		if lf[i] == ' ' {
+			begin = i + 1
+			break
+		}
+	}
+	longForm := lf[begin:]
+
+	// --------------------------------------------------------------------------------------------
+	// step 3: Check the size of the long form
+	numWords := 1
+	for _, r := range longForm {
+		if r == ' ' {
+			numWords++
+		}
+	}
+	maxNumWords := len(sf) + 5
+	if 2*len(sf) < maxNumWords {
+		maxNumWords = 2 * len(sf)
+	}
+	if numWords > maxNumWords || len(longForm) <= len(sf) {
+		return 0
+	}
+	return numWords
+}
+
+// =================================================================================================
+// function findAcronymDefinitions
+// brief description:
+//   Find the acronym definitions written as "long form (SF)" or "SF (long form)".
+// input:
+//   tokens: The tokens of a text, puntuations included.
+// output:
+//   The acronym definitions found in the tokens.
+
+func findAcronymDefinitions(tokens []string) []acronymDefinition {
+	result := []acronymDefinition{}
+	numTokens := len(tokens)
+	for idxOpen, token := range tokens {
+		// --------------------------------------------------------------------------------------------
+		// step 1: Find a parenthetical with no more than 10 words in it
+		if token != "(" && token != "（" {
+			continue
+		}
+		idxClose := -1
+		for i := idxOpen + 1; i < numTokens && i <= idxOpen+11; i++ {
+			if tokens[i] == ")" || tokens[i] == "）" {
+				idxClose = i
+				break
+			}
+			if punctuations[tokens[i]] {
+				break
+			}
+		}
+		if idxClose < 0 || idxClose == idxOpen+1 {
+			continue
+		}
+		enclosed := tokens[idxOpen+1 : idxClose]
+
+		// --------------------------------------------------------------------------------------------
+		// step 2: Try the pattern "long form (SF)"
+		if len(enclosed) == 1 && isShortFormCandidate(enclosed[0]) {
+			shortForm := normalizeAcronym(enclosed[0])
+			maxNumWords := len(shortForm) + 5
+			if 2*len(shortForm) < maxNumWords {
+				maxNumWords = 2 * len(shortForm)
+			}
+			begin := idxOpen
+			for begin > 0 && !punctuations[tokens[begin-1]] && idxOpen-begin < maxNumWords {
+				begin--
+			}
+			numWords := findBestLongForm(shortForm, tokens[begin:idxOpen])
+			if numWords > 0 {
+				result = append(result, acronymDefinition{
+					shortForm: shortForm,
+					longForm:  tokens[idxOpen-numWords : idxOpen],
+					dropBegin: idxOpen + 1,
+					dropEnd:   idxClose + 1,
+				})
+			}
+			continue
+		}
+
+		// --------------------------------------------------------------------------------------------
+		// step 3: Try the pattern "SF (long form)"
+		if idxOpen > 0 && len(enclosed) > 1 && isShortFormCandidate(tokens[idxOpen-1]) {
+			shortForm := normalizeAcronym(tokens[idxOpen-1])
+			numWords := findBestLongForm(shortForm, enclosed)
+			if numWords > 0 {
+				result = append(result, acronymDefinition{
+					shortForm: shortForm,
+					longForm:  enclosed[len(enclosed)-numWords:],
+					dropBegin: idxOpen + 1,
+					dropEnd:   idxClose + 1,
+				})
+			}
+		}
+	}
+	return result
+}
+
+// =================================================================================================
+// function expandAcronyms
+// brief description:
+//   Normalize plural acronyms and replace each mention of a defined acronym with its long form, so
+//   that the acronym and its long form are counted as a single candidate.
+// input:
+//   tokens: The tokens of a text, puntuations included.
+//   definitions: The acronym definitions found in the tokens.
+// output:
+//   The converted tokens, and the conversions that have been made.
+
+func expandAcronyms(tokens []token, definitions []acronymDefinition) ([]token, []Conversion) {
+	// --------------------------------------------------------------------------------------------
+	// step 1: Index the definitions
+	longForms := map[string][]string{}
+	dropped := map[int]bool{}
+	for _, definition := range definitions {
+		if _, exists := longForms[definition.shortForm]; !exists {
+			longForms[definition.shortForm] = definition.longForm
+		}
+		for i := definition.dropBegin; i < definition.dropEnd; i++ {
+			dropped[i] = true
+		}
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 2: Convert the tokens
+	texts := tokenTexts(tokens)
+	result := make([]token, 0, len(tokens))
+	conversions := []Conversion{}
+	for idx, tok := range tokens {
+		if dropped[idx] {
+			continue
+		}
+		word := normalizeAcronym(tok.text)
+		if word != tok.text {
+			conversions = append(conversions, Conversion{
+				Kind:      ConversionAcronymPlural,
+				Original:  tok.text,
+				Converted: word,
+				Begin:     tok.begin,
+				End:       tok.end,
+			})
+		}
+		// a roman number after a cue word, e.g. "Part IV", is not an acronym
+		longForm, isDefined := longForms[word]
+		if isDefined && convertRomanToArabic(word) != word && followsRomanCue(texts, idx) {
+			isDefined = false
+		}
+		if isDefined {
+			for _, longFormWord := range longForm {
+				expanded := tok
+				expanded.text = longFormWord
+				result = append(result, expanded)
+			}
+			conversions = append(conversions, Conversion{
+				Kind:      ConversionAcronymExpansion,
+				Original:  word,
+				Converted: strings.Join(longForm, " "),
+				Begin:     tok.begin,
+				End:       tok.end,
+			})
+		} else {
+			tok.text = word
+			result = append(result, tok)
+		}
+	}
+	return result, conversions
+}
+
+// =================================================================================================
+// function FindAcronymDefinitions
+// brief description:
+//   Find the acronym definitions in the input text, e.g. "Support Vector Machine (SVM)".
+// input:
+//   text: The input text.
+// output:
+//   A map from the (singular) short forms to their long forms.
+
+func FindAcronymDefinitions(text string) map[string]string {
+	result := map[string]string{}
+	for _, definition := range findAcronymDefinitions(tokenTexts(tokenizeText(text, false))) {
+		if _, exists := result[definition.shortForm]; !exists {
+			result[definition.shortForm] = strings.Join(definition.longForm, " ")
+		}
+	}
+	return result
+}
@@ -0,0 +1,234 @@
+package KeyphraseExtraction
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// BatchOptions controls a batch extraction.
+type BatchOptions struct {
+	// Options are the options of the pipeline.
+	Options Options
+
+	// NumWorkers is the number of documents processed at the same time. 0 means runtime.NumCPU().
+	NumWorkers int
+}
+
+// BatchResult is the result of a document of a batch extraction.
+type BatchResult struct {
+	// Index is the index of the document in the input.
+	Index int
+
+	// Document is the processed document, or nil if Err is not nil.
+	Document *Document
+
+	// Err tells why the document has not been processed, e.g. the context has been cancelled.
+	Err error
+}
+
+// =================================================================================================
+// method BatchOptions.numWorkers
+// brief description:
+//   Get the number of workers of a batch extraction.
+// output:
+//   NumWorkers, or runtime.NumCPU() if it is not positive.
+
+func (options BatchOptions) numWorkers() int {
+	if options.NumWorkers > 0 {
+		return options.NumWorkers
+	}
+	return runtime.NumCPU()
+}
+
+// =================================================================================================
+// function extractSafely
+// brief description:
+//   Process a document, turning a panic of the pipeline into an error of this document.
+// input:
+//   extractor: The extractor.
+//   index: The index of the document.
+//   text: The text of the document.
+// output:
+//   The result of the document.
+
+func extractSafely(extractor *Extractor, index int, text string) (result BatchResult) {
+	result.Index = index
+	defer func() {
+		if r := recover(); r != nil {
+			result.Document = nil
+			result.Err = fmt.Errorf("KeyphraseExtraction: document %d: %v", index, r)
+		}
+	}()
+	result.Document = extractor.ExtractDocument(text)
+	return result
+}
+
+// =================================================================================================
+// function ExtractBatch
+// brief description:
+//   Process a batch of documents with a bounded pool of workers.
+// input:
+//   ctx: The context, whose cancellation stops the extraction.
+//   docs: The texts of the documents.
+//   options: The options of the batch extraction.
+// output:
+//   The results of the documents, in the order of docs. The documents not processed because of the
+//   cancellation of ctx have ctx.Err() as their errors.
+
+func ExtractBatch(ctx context.Context, docs []string, options BatchOptions) []BatchResult {
+	// --------------------------------------------------------------------------------------------
+	// step 1: Start the workers
+	extractor := NewExtractor(options.Options)
+	numDocs := len(docs)
+	result := make([]BatchResult, numDocs)
+	done := make([]bool, numDocs)
+	chI := make(chan int)
+	var wg sync.WaitGroup
+	for idxWorker := 0; idxWorker < options.numWorkers(); idxWorker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range chI {
+				if ctx.Err() != nil {
+					continue
+				}
+				result[i] = extractSafely(extractor, i, docs[i])
+				done[i] = true
+			}
+		}()
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 2: Dispatch the documents until all are dispatched or the context is cancelled
+dispatch:
+	for i := 0; i < numDocs; i++ {
+		select {
+		case chI <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(chI)
+	wg.Wait()
+
+	// --------------------------------------------------------------------------------------------
+	// step 3: Report the documents that have not been processed
+	for i := 0; i < numDocs; i++ {
+		if !done[i] {
+			result[i] = BatchResult{Index: i, Err: ctx.Err()}
+		}
+	}
+	return result
+}
+
+// =================================================================================================
+// function ExtractStream
+// brief description:
+//   Process a stream of documents with a bounded pool of workers.
+// input:
+//   ctx: The context, whose cancellation stops the extraction.
+//   docs: The texts of the documents.
+//   options: The options of the batch extraction.
+// output:
+//   A channel of the results, in the order of docs, which is closed when docs is closed and all
+//   its documents are reported, or as soon as the documents in progress are finished after ctx is
+//   cancelled. After the cancellation, no more results are sent, and the documents not yet
+//   received from docs are left in it.
+// notes:
+//   At most 2 * NumWorkers documents are held in memory at a time, no matter how slowly the results
+//   are consumed.
+
+func ExtractStream(ctx context.Context, docs <-chan string, options BatchOptions) <-chan BatchResult {
+	extractor := NewExtractor(options.Options)
+	numWorkers := options.numWorkers()
+	type job struct {
+		index int
+		text  string
+	}
+	chJob := make(chan job)
+	chDone := make(chan BatchResult)
+	chResult := make(chan BatchResult)
+	slots := make(chan struct{}, 2*numWorkers)
+
+	// --------------------------------------------------------------------------------------------
+	// step 1: Dispatch the documents, holding a slot for each document until it is reported
+	go func() {
+		defer close(chJob)
+		for index := 0; ; index++ {
+			// a select with both cases ready picks either, so that the cancellation is checked first
+			if ctx.Err() != nil {
+				return
+			}
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			select {
+			case text, ok := <-docs:
+				if !ok {
+					return
+				}
+				chJob <- job{index: index, text: text}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// --------------------------------------------------------------------------------------------
+	// step 2: Process the documents
+	var wg sync.WaitGroup
+	for idxWorker := 0; idxWorker < numWorkers; idxWorker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range chJob {
+				if ctx.Err() != nil {
+					chDone <- BatchResult{Index: j.index, Err: ctx.Err()}
+				} else {
+					chDone <- extractSafely(extractor, j.index, j.text)
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(chDone)
+	}()
+
+	// --------------------------------------------------------------------------------------------
+	// step 3: Report the results in the order of the documents
+	go func() {
+		defer close(chResult)
+		pending := map[int]BatchResult{}
+		next := 0
+		cancelled := false
+		for done := range chDone {
+			pending[done.Index] = done
+			for {
+				nextResult, exists := pending[next]
+				if !exists {
+					break
+				}
+				delete(pending, next)
+				next++
+				cancelled = cancelled || ctx.Err() != nil
+				if !cancelled {
+					select {
+					case chResult <- nextResult:
+					case <-ctx.Done():
+						cancelled = true
+					}
+				}
+				<-slots
+			}
+		}
+	}()
+	return chResult
+}
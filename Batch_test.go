@@ -0,0 +1,145 @@
+package KeyphraseExtraction
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// streamTexts sends texts to a channel until they are all sent or ctx is cancelled
+func streamTexts(ctx context.Context, texts []string) <-chan string {
+	result := make(chan string)
+	go func() {
+		defer close(result)
+		for _, text := range texts {
+			select {
+			case result <- text:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return result
+}
+
+// collectResults receives the results of a stream, failing if it is not closed in time
+func collectResults(t *testing.T, results <-chan BatchResult, onResult func(BatchResult)) []BatchResult {
+	t.Helper()
+	collected := []BatchResult{}
+	timeout := time.After(30 * time.Second)
+	for {
+		select {
+		case result, ok := <-results:
+			if !ok {
+				return collected
+			}
+			collected = append(collected, result)
+			if onResult != nil {
+				onResult(result)
+			}
+		case <-timeout:
+			t.Fatalf("the stream is not closed after %d results", len(collected))
+		}
+	}
+}
+
+func TestExtractStreamOrder(t *testing.T) {
+	// long and short documents, so that the workers finish them out of order
+	texts := make([]string, 200)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("Document %d is about neural networks.", i)
+		if i%3 == 0 {
+			texts[i] += strings.Repeat(" Deep learning of graph neural networks scales.", 50)
+		}
+	}
+	results := collectResults(t, ExtractStream(context.Background(), streamTexts(context.Background(), texts),
+		BatchOptions{Options: DefaultOptions(), NumWorkers: 8}), nil)
+	if len(results) != len(texts) {
+		t.Fatalf("%d results, want %d", len(results), len(texts))
+	}
+	for i, result := range results {
+		if result.Index != i || result.Err != nil || result.Document == nil {
+			t.Fatalf("result %d: index %d, error %v", i, result.Index, result.Err)
+		}
+		if got, want := result.Document.CandidatePhrases(), ExtractKeyPhraseCandidates(texts[i]); fmt.Sprint(got) !=
+			fmt.Sprint(want) {
+			t.Errorf("result %d: candidates %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestExtractStreamCancel(t *testing.T) {
+	texts := make([]string, 1000)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("Document %d is about neural networks.", i)
+	}
+
+	// the stream stops soon after the cancellation, with the results in order until then
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	results := collectResults(t, ExtractStream(ctx, streamTexts(ctx, texts), BatchOptions{NumWorkers: 4}),
+		func(result BatchResult) {
+			if result.Index == 9 {
+				cancel()
+			}
+		})
+	if len(results) < 10 || len(results) >= len(texts) {
+		t.Errorf("%d results after the cancellation at 10", len(results))
+	}
+	for i, result := range results {
+		if result.Index != i {
+			t.Fatalf("result %d has index %d", i, result.Index)
+		}
+	}
+
+	// a stream cancelled before it starts sends nothing, and leaves the documents in their channel
+	cancelled, cancelNow := context.WithCancel(context.Background())
+	cancelNow()
+	docs := make(chan string, 1)
+	docs <- texts[0]
+	if results := collectResults(t, ExtractStream(cancelled, docs, BatchOptions{}), nil); len(results) != 0 {
+		t.Errorf("%d results of a cancelled stream", len(results))
+	}
+	if len(docs) != 1 {
+		t.Error("the cancelled stream has taken a document")
+	}
+}
+
+func TestExtractStreamPanic(t *testing.T) {
+	// a stem cache without its maps panics on the first word, so that only the empty texts succeed
+	texts := []string{"", "Neural networks.", "", "Deep learning.", ""}
+	options := BatchOptions{Options: DefaultOptions(), NumWorkers: 2}
+	options.Options.StemCache = &StemCache{}
+	results := collectResults(t, ExtractStream(context.Background(), streamTexts(context.Background(), texts),
+		options), nil)
+	if len(results) != len(texts) {
+		t.Fatalf("%d results, want %d", len(results), len(texts))
+	}
+	for i, result := range results {
+		failed := texts[i] != ""
+		if result.Index != i || (result.Err != nil) != failed || (result.Document == nil) != failed {
+			t.Errorf("result %d: index %d, error %v, document %v", i, result.Index, result.Err, result.Document)
+		}
+		if failed && !strings.Contains(result.Err.Error(), fmt.Sprint("document ", i)) {
+			t.Errorf("result %d: error %q does not name the document", i, result.Err)
+		}
+	}
+}
+
+func TestExtractBatch(t *testing.T) {
+	texts := []string{"Neural networks.", "Deep learning.", "Graph neural networks."}
+	for i, result := range ExtractBatch(context.Background(), texts, BatchOptions{NumWorkers: 2}) {
+		if result.Index != i || result.Err != nil || result.Document == nil {
+			t.Errorf("result %d: index %d, error %v", i, result.Index, result.Err)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i, result := range ExtractBatch(ctx, texts, BatchOptions{}) {
+		if result.Index != i || result.Err != context.Canceled {
+			t.Errorf("cancelled result %d: index %d, error %v", i, result.Index, result.Err)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package KeyphraseExtraction
+
+import (
+	"strings"
+	"unicode"
+)
+
+// CandidateFilter holds the rules that drop poor key phrase candidates. A zero value in a field
+// disables the corresponding rule.
+type CandidateFilter struct {
+	// MinWords and MaxWords bound the number of words in a candidate.
+	MinWords int
+	MaxWords int
+
+	// MinChars is the minimum number of letters and digits in a candidate.
+	MinChars int
+
+	// MaxDigitRatio is the maximum ratio of digits among the letters and digits of a candidate.
+	MaxDigitRatio float64
+
+	// ShapeBlacklist holds the shapes (see WordShape) of the words that cannot be part of a
+	// candidate, e.g. "'x" for "'s". Such words separate candidates as stop words do.
+	ShapeBlacklist map[string]bool
+}
+
+// =================================================================================================
+// function DefaultCandidateFilter
+// brief description:
+//   Get the recommended rules of candidate filtering, which are not applied unless they are set in
+//   Options.Filter.
+// output:
+//   A filter that drops single chars, and words such as "'s", "n't" and "&".
+
+func DefaultCandidateFilter() CandidateFilter {
+	shapeBlacklist := map[string]bool{}
+	for _, shape := range []string{
+		"'x", "x'x", "-", "/", "&", "*", "+", "=", "|", "~", "^", "<", ">", "[", "]", "{", "}", "#",
+		"@",
+	} {
+		shapeBlacklist[shape] = true
+	}
+	return CandidateFilter{
+		MinWords:       1,
+		MinChars:       2,
+		ShapeBlacklist: shapeBlacklist,
+	}
+}
+
+// =================================================================================================
+// function WordShape
+// brief description:
+//   Get the shape of a word, where each run of uppercase letters becomes "X", each run of lowercase
+//   letters becomes "x", each run of digits becomes "d", and other chars are kept.
+// input:
+//   word: The input word.
+// output:
+//   The shape of the word, e.g. "Xx" for "Hello", "'x" for "'s" and "dx" for "3d".
+
+func WordShape(word string) string {
+	var builder strings.Builder
+	var prevClass rune
+	for _, r := range word {
+		class := r
+		switch {
+		case unicode.IsUpper(r):
+			class = 'X'
+		case unicode.IsLetter(r):
+			class = 'x'
+		case unicode.IsDigit(r):
+			class = 'd'
+		}
+		if class != prevClass || class != 'X' && class != 'x' && class != 'd' {
+			builder.WriteRune(class)
+		}
+		prevClass = class
+	}
+	return builder.String()
+}
+
+// =================================================================================================
+// method CandidateFilter.accepts
+// brief description:
+//   Check a candidate phrase against the word count, char count and digit ratio rules.
+// input:
+//   phrase: The words of a candidate phrase.
+// output:
+//   true if the candidate passes the rules, false otherwise.
+
+func (filter CandidateFilter) accepts(phrase []string) bool {
+	// --------------------------------------------------------------------------------------------
+	// step 1: the word count
+	numWords := len(phrase)
+	if numWords == 0 || numWords < filter.MinWords || filter.MaxWords > 0 && numWords > filter.MaxWords {
+		return false
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 2: the char count and the digit ratio
+	numChars := 0
+	numDigits := 0
+	for _, word := range phrase {
+		for _, r := range word {
+			if unicode.IsDigit(r) {
+				numChars++
+				numDigits++
+			} else if unicode.IsLetter(r) {
+				numChars++
+			}
+		}
+	}
+	if numChars < filter.MinChars {
+		return false
+	}
+	if filter.MaxDigitRatio > 0 && numChars > 0 && float64(numDigits)/float64(numChars) > filter.MaxDigitRatio {
+		return false
+	}
+	return true
+}
+
+// =================================================================================================
+// method CandidateFilter.separate
+// brief description:
+//   Use the words of blacklisted shapes to separate candidate phrases further.
+// input:
+//   words: The words of a text.
+//   spans: The spans of the candidate phrases.
+// output:
+//   The spans of the candidate phrases without blacklisted words.
+
+func (filter CandidateFilter) separate(words []string, spans []tokenSpan) []tokenSpan {
+	if len(filter.ShapeBlacklist) == 0 {
+		return spans
+	}
+	result := []tokenSpan{}
+	for _, span := range spans {
+		begin := span.begin
+		for idxWord := span.begin; idxWord < span.end; idxWord++ {
+			if filter.ShapeBlacklist[WordShape(words[idxWord])] {
+				if idxWord > begin {
+					result = append(result, tokenSpan{begin: begin, end: idxWord})
+				}
+				begin = idxWord + 1
+			}
+		}
+		if span.end > begin {
+			result = append(result, tokenSpan{begin: begin, end: span.end})
+		}
+	}
+	return result
+}
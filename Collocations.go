@@ -0,0 +1,245 @@
+package KeyphraseExtraction
+
+import (
+	"math"
+	"strings"
+)
+
+// CollocationMeasure is an association measure between the words of a multiword phrase.
+type CollocationMeasure int
+
+const (
+	// MeasurePMI is the pointwise mutual information, in bits.
+	MeasurePMI CollocationMeasure = iota
+
+	// MeasureNPMI is the pointwise mutual information normalized into [-1, 1].
+	MeasureNPMI
+
+	// MeasureLogLikelihood is Dunning's log-likelihood ratio.
+	MeasureLogLikelihood
+
+	// MeasureTScore is the t-score.
+	MeasureTScore
+
+	// MeasureChiSquare is Pearson's chi-square.
+	MeasureChiSquare
+)
+
+// Collocation holds the association measures of a multiword phrase. For n > 2 words, each measure
+// is the lowest over the splits of the phrase into two parts, e.g. "support vector machine" is
+// measured as "support" + "vector machine" and as "support vector" + "machine", so that a phrase is
+// only as strong as its weakest split.
+type Collocation struct {
+	Frequency     uint
+	PMI           float64
+	NPMI          float64
+	LogLikelihood float64
+	TScore        float64
+	ChiSquare     float64
+}
+
+// =================================================================================================
+// method Collocation.Measure
+// brief description:
+//   Get an association measure of the phrase.
+// input:
+//   measure: the association measure
+// output:
+//   the value of the measure
+
+func (collocation Collocation) Measure(measure CollocationMeasure) float64 {
+	switch measure {
+	case MeasurePMI:
+		return collocation.PMI
+	case MeasureNPMI:
+		return collocation.NPMI
+	case MeasureLogLikelihood:
+		return collocation.LogLikelihood
+	case MeasureTScore:
+		return collocation.TScore
+	case MeasureChiSquare:
+		return collocation.ChiSquare
+	}
+	return math.NaN()
+}
+
+// =================================================================================================
+// function logLikelihoodTerm
+// brief description:
+//   Compute a term of the log-likelihood ratio.
+// input:
+//   observed, expected: the observed and the expected frequencies of a cell of the contingency table
+// output:
+//   observed * ln(observed / expected), or 0 if observed is not positive
+
+func logLikelihoodTerm(observed, expected float64) float64 {
+	if observed <= 0 || expected <= 0 {
+		return 0.0
+	}
+	return observed * math.Log(observed/expected)
+}
+
+// =================================================================================================
+// function measureSplit
+// brief description:
+//   Compute the association measures of two parts of a phrase from a 2x2 contingency table.
+// input:
+//   o11: the frequency of the phrase
+//   r1, c1: the frequencies of the first and the second parts
+//   n: the number of words counted
+// output:
+//   the measures, the frequency left empty
+
+func measureSplit(o11, r1, c1, n float64) Collocation {
+	o12 := r1 - o11
+	o21 := c1 - o11
+	o22 := n - r1 - c1 + o11
+	r2 := n - r1
+	c2 := n - c1
+	e11 := r1 * c1 / n
+
+	result := Collocation{}
+	result.PMI = math.Log2(o11 / e11)
+	if o11 < n {
+		result.NPMI = result.PMI / -math.Log2(o11/n)
+	} else {
+		result.NPMI = 1.0
+	}
+	result.TScore = (o11 - e11) / math.Sqrt(o11)
+	if r1 > 0 && r2 > 0 && c1 > 0 && c2 > 0 {
+		d := o11*o22 - o12*o21
+		result.ChiSquare = n * d * d / (r1 * r2 * c1 * c2)
+	}
+	result.LogLikelihood = 2.0 * (logLikelihoodTerm(o11, e11) +
+		logLikelihoodTerm(o12, r1*c2/n) +
+		logLikelihoodTerm(o21, r2*c1/n) +
+		logLikelihoodTerm(o22, r2*c2/n))
+	return result
+}
+
+// =================================================================================================
+// function Collocations
+// brief description:
+//   Compute the association measures of the multiword n-grams of a set of key phrase candidates,
+//   from their occurrences in a set of auxiliary phrases.
+// input:
+//   phraseCandidates: a set of key phrase candidates
+//   auxPhrases: an array of auxiliary phrases, e.g. the candidates of all the documents of a corpus
+// output:
+//   the measures of each multiword n-gram of the candidates that occurs in auxPhrases
+// notes:
+//   The n-grams are counted as TF counts them, and the number of words of auxPhrases is the size of
+//   the sample. The references are:
+//   Church, K. W., & Hanks, P. (1990). Word association norms, mutual information, and
+//   lexicography. Computational Linguistics, 16(1), 22-29.
+//   Dunning, T. (1993). Accurate methods for the statistics of surprise and coincidence.
+//   Computational Linguistics, 19(1), 61-74.
+
+func Collocations(phraseCandidates []string, auxPhrases []string) map[string]Collocation {
+	// --------------------------------------------------------------------------------------------
+	// step 1: count the n-grams of the candidates in auxPhrases
+	vocabulary := NewVocabulary()
+	candidates := vocabulary.EncodeAll(phraseCandidates)
+	frequency := map[NGramKey]float64{}
+	for _, candidate := range candidates {
+		forEachNGram(candidate, 0, func(begin, end int, key NGramKey) bool {
+			frequency[key] = 0.0
+			return true
+		})
+	}
+	numWords := 0.0
+	for _, auxPhrase := range vocabulary.EncodeAll(auxPhrases) {
+		numWords += float64(len(auxPhrase))
+		// the n-grams of the candidates are closed under taking parts, so that an unknown n-gram
+		// has no known longer n-gram starting at the same word
+		forEachNGram(auxPhrase, 0, func(begin, end int, key NGramKey) bool {
+			if _, exists := frequency[key]; !exists {
+				return false
+			}
+			frequency[key] += 1.0
+			return true
+		})
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 2: measure each multiword n-gram over its splits
+	texts := map[NGramKey]string{}
+	vocabulary.nGramTexts(candidates, 0, texts)
+	result := map[string]Collocation{}
+	for _, candidate := range candidates {
+		forEachNGram(candidate, 0, func(begin, end int, key NGramKey) bool {
+			o11 := frequency[key]
+			if end-begin < 2 || o11 == 0 {
+				return true
+			}
+			text := texts[key]
+			if _, exists := result[text]; exists {
+				return true
+			}
+			collocation := Collocation{Frequency: uint(o11)}
+			for split := begin + 1; split < end; split++ {
+				measures := measureSplit(o11, frequency[NGramKeyOf(candidate[begin:split])],
+					frequency[NGramKeyOf(candidate[split:end])], numWords)
+				if split == begin+1 {
+					measures.Frequency = collocation.Frequency
+					collocation = measures
+					continue
+				}
+				collocation.PMI = math.Min(collocation.PMI, measures.PMI)
+				collocation.NPMI = math.Min(collocation.NPMI, measures.NPMI)
+				collocation.LogLikelihood = math.Min(collocation.LogLikelihood, measures.LogLikelihood)
+				collocation.TScore = math.Min(collocation.TScore, measures.TScore)
+				collocation.ChiSquare = math.Min(collocation.ChiSquare, measures.ChiSquare)
+			}
+			result[text] = collocation
+			return true
+		})
+	}
+	return result
+}
+
+// =================================================================================================
+// function CollocationScores
+// brief description:
+//   Get an association measure of each phrase, as a ranking feature.
+// input:
+//   collocations: the measures returned by Collocations
+//   measure: the association measure
+// output:
+//   the value of the measure for each phrase, which can be sorted with ArgSort
+
+func CollocationScores(collocations map[string]Collocation, measure CollocationMeasure) map[string]float64 {
+	result := make(map[string]float64, len(collocations))
+	for text, collocation := range collocations {
+		result[text] = collocation.Measure(measure)
+	}
+	return result
+}
+
+// =================================================================================================
+// function FilterCollocations
+// brief description:
+//   Drop the multiword candidates whose words are not associated strongly enough.
+// input:
+//   phraseCandidates: the key phrase candidates
+//   collocations: the measures returned by Collocations
+//   measure: the association measure
+//   threshold: the lowest value of the measure kept
+// output:
+//   the single-word candidates, and the multiword candidates whose measure reaches threshold
+
+func FilterCollocations(phraseCandidates []string, collocations map[string]Collocation,
+	measure CollocationMeasure, threshold float64) []string {
+	result := []string{}
+	for _, candidate := range phraseCandidates {
+		if !strings.Contains(candidate, " ") {
+			result = append(result, candidate)
+			continue
+		}
+		collocation, exists := collocations[candidate]
+		if exists && collocation.Measure(measure) >= threshold {
+			result = append(result, candidate)
+		}
+	}
+	return result
+}
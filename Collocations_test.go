@@ -0,0 +1,80 @@
+package KeyphraseExtraction
+
+import (
+	"math"
+	"testing"
+)
+
+func checkCollocation(t *testing.T, name string, got, want Collocation) {
+	t.Helper()
+	measures := []CollocationMeasure{MeasurePMI, MeasureNPMI, MeasureLogLikelihood, MeasureTScore, MeasureChiSquare}
+	for _, measure := range measures {
+		gotValue, wantValue := got.Measure(measure), want.Measure(measure)
+		if math.Abs(gotValue-wantValue) > 1e-9*math.Max(1, math.Abs(wantValue)) {
+			t.Errorf("%s: measure %d is %v, want %v", name, measure, gotValue, wantValue)
+		}
+	}
+	if got.Frequency != want.Frequency {
+		t.Errorf("%s: frequency %d, want %d", name, got.Frequency, want.Frequency)
+	}
+}
+
+func TestMeasureSplit(t *testing.T) {
+	// "new companies" in Manning & Schuetze (1999), section 5.3: 8 occurrences, 15828 of "new",
+	// 4675 of "companies", in 14307668 words, with a t-score of 0.999932 and a chi-square of 1.55
+	got := measureSplit(8, 15828, 4675, 14307668)
+	if math.Abs(got.TScore-0.999932) > 1e-6 || math.Abs(got.ChiSquare-1.55) > 0.005 {
+		t.Errorf("t-score %v and chi-square %v, want 0.999932 and 1.55", got.TScore, got.ChiSquare)
+	}
+	e11 := 15828.0 * 4675.0 / 14307668.0
+	if want := math.Log2(8 / e11); math.Abs(got.PMI-want) > 1e-12 {
+		t.Errorf("PMI %v, want %v", got.PMI, want)
+	}
+}
+
+func TestCollocations(t *testing.T) {
+	// 13 words, in which a, b and c occur 3 times, "a b" 3 times, "b c" and "a b c" twice
+	aux := []string{"a b c", "a b c", "a b", "c", "d", "d", "d", "d"}
+	collocations := Collocations([]string{"a b c", "d"}, aux)
+	if len(collocations) != 3 {
+		t.Fatalf("collocations %v, want a b, b c and a b c", collocations)
+	}
+
+	// "a b": o11 = 3, o12 = o21 = 0, o22 = 10, and e11 = 9/13, e12 = e21 = 30/13, e22 = 100/13
+	checkCollocation(t, "a b", collocations["a b"], Collocation{
+		Frequency:     3,
+		PMI:           math.Log2(13.0 / 3.0),
+		NPMI:          1.0,
+		LogLikelihood: 2 * (3*math.Log(13.0/3.0) + 10*math.Log(130.0/100.0)),
+		TScore:        (3 - 9.0/13.0) / math.Sqrt(3),
+		ChiSquare:     13,
+	})
+
+	// "b c": o11 = 2, o12 = o21 = 1, o22 = 9, and the same expected frequencies
+	bc := Collocation{
+		Frequency:     2,
+		PMI:           math.Log2(26.0 / 9.0),
+		NPMI:          math.Log2(26.0/9.0) / math.Log2(13.0/2.0),
+		LogLikelihood: 2 * (2*math.Log(26.0/9.0) + 2*math.Log(13.0/30.0) + 9*math.Log(117.0/100.0)),
+		TScore:        (2 - 9.0/13.0) / math.Sqrt(2),
+		ChiSquare:     13.0 * 17 * 17 / (3 * 10 * 3 * 10),
+	}
+	checkCollocation(t, "b c", collocations["b c"], bc)
+
+	// "a b c" is measured as "a" + "b c", with e11 = 6/13 and a chi-square of 13 * 20^2 / 660, and as
+	// "a b" + "c", whose table is the one of "b c", which is lower for every measure
+	split := measureSplit(2, 3, 2, 13)
+	if math.Abs(split.ChiSquare-13.0*400/660) > 1e-9 || split.PMI <= bc.PMI ||
+		split.LogLikelihood <= bc.LogLikelihood {
+		t.Errorf("split a + b c: %+v", split)
+	}
+	checkCollocation(t, "a b c", collocations["a b c"], bc)
+}
+
+func TestFilterCollocations(t *testing.T) {
+	collocations := map[string]Collocation{"a b": {PMI: 2}, "b c": {PMI: 0.5}}
+	got := FilterCollocations([]string{"a", "a b", "b c", "x y"}, collocations, MeasurePMI, 1)
+	if len(got) != 2 || got[0] != "a" || got[1] != "a b" {
+		t.Errorf("FilterCollocations = %q, want [a a b]", got)
+	}
+}
@@ -0,0 +1,266 @@
+package KeyphraseExtraction
+
+import (
+	"math"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// CooccurrenceContext tells which words around a phrase are its context.
+type CooccurrenceContext int
+
+const (
+	// ContextSentence takes the words of the sentence of the phrase.
+	ContextSentence CooccurrenceContext = iota
+
+	// ContextWindow takes the words at most Window words before or after the phrase, in its
+	// sentence.
+	ContextWindow
+)
+
+// CooccurrenceSimilarityOptions controls the phrase similarity built from co-occurrences.
+type CooccurrenceSimilarityOptions struct {
+	// Context tells which words around a phrase are its context.
+	Context CooccurrenceContext
+
+	// Window is the size of the window of ContextWindow. 0 means 5.
+	Window int
+
+	// Dimensions is the number of dimensions kept by a truncated SVD of the PPMI matrix. 0 means
+	// no reduction, comparing the sparse PPMI vectors.
+	Dimensions int
+
+	// Threshold is the lowest similarity kept between two different phrases. 0 means 0.5, as in
+	// EmbeddingSimilarityOptions.
+	Threshold float64
+
+	// TopK is the number of nearest neighbors of each phrase, itself excluded, that are kept, as in
+	// EmbeddingSimilarityOptions. 0 means no limit.
+	TopK int
+}
+
+// =================================================================================================
+// function countCooccurrences
+// brief description:
+//   Count the context words of the candidates of some documents, and of their n-grams.
+// input:
+//   documents: the processed documents
+//   options: the options of the context
+// output:
+//   the number of times each stemmed context word occurs around each stemmed phrase
+// notes:
+//   The n-grams of a candidate share the context of the candidate.
+
+func countCooccurrences(documents []*Document, options CooccurrenceSimilarityOptions) map[string]map[string]float64 {
+	window := options.Window
+	if window <= 0 {
+		window = 5
+	}
+	result := map[string]map[string]float64{}
+	for _, document := range documents {
+		for _, candidate := range document.Candidates {
+			// the context words of the candidate
+			sentence := document.Sentences[candidate.Sentence]
+			begin, end := sentence.Begin, sentence.End
+			if options.Context == ContextWindow {
+				if candidate.Begin-window > begin {
+					begin = candidate.Begin - window
+				}
+				if candidate.End+window < end {
+					end = candidate.End + window
+				}
+			}
+			context := []string{}
+			for i := begin; i < end; i++ {
+				if (i < candidate.Begin || i >= candidate.End) && isContextWord(document.Tokens[i]) {
+					context = append(context, document.Tokens[i].Stem)
+				}
+			}
+
+			// the n-grams of the candidate
+			words := strings.Split(candidate.Phrase, " ")
+			numWords := len(words)
+			for i := 0; i < numWords; i++ {
+				for j := i + 1; j <= numWords; j++ {
+					phrase := strings.Join(words[i:j], " ")
+					counts := result[phrase]
+					if counts == nil {
+						counts = map[string]float64{}
+						result[phrase] = counts
+					}
+					for _, word := range context {
+						counts[word] += 1.0
+					}
+				}
+			}
+		}
+	}
+	return result
+}
+
+// =================================================================================================
+// function ppmiRows
+// brief description:
+//   Weight co-occurrence counts with positive pointwise mutual information.
+// input:
+//   counts: the co-occurrence counts of each phrase with each context word
+// output:
+//   the phrases, the rows of their PPMI vectors over the indexed context words, and the number of
+//   context words
+
+func ppmiRows(counts map[string]map[string]float64) ([]string, []sparseRow, int) {
+	// --------------------------------------------------------------------------------------------
+	// step 1: index the context words and sum the counts
+	contextIndex := map[string]int{}
+	contextSum := []float64{}
+	total := 0.0
+	for _, row := range counts {
+		for word, count := range row {
+			idx, exists := contextIndex[word]
+			if !exists {
+				idx = len(contextSum)
+				contextIndex[word] = idx
+				contextSum = append(contextSum, 0.0)
+			}
+			contextSum[idx] += count
+			total += count
+		}
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 2: weight each count with max(0, log(p(phrase, word) / (p(phrase) p(word))))
+	phrases := []string{}
+	rows := []sparseRow{}
+	for phrase, row := range counts {
+		rowSum := 0.0
+		for _, count := range row {
+			rowSum += count
+		}
+		weighted := sparseRow{}
+		for word, count := range row {
+			idx := contextIndex[word]
+			pmi := math.Log(count * total / (rowSum * contextSum[idx]))
+			if pmi > 0 {
+				weighted.columns = append(weighted.columns, idx)
+				weighted.values = append(weighted.values, pmi)
+			}
+		}
+		if len(weighted.columns) > 0 {
+			phrases = append(phrases, phrase)
+			rows = append(rows, weighted)
+		}
+	}
+	return phrases, rows, len(contextSum)
+}
+
+// =================================================================================================
+// function sparseSimilarity
+// brief description:
+//   Build a sparse similarity matrix from sparse phrase vectors, comparing the pairs of phrases
+//   that share a column.
+// input:
+//   phrases: the phrases
+//   rows: the non-zero vectors of the phrases
+//   threshold: the lowest similarity kept between two different phrases
+//   topK: the number of nearest neighbors of each phrase kept, or 0 for no limit
+// output:
+//   the symmetric cosine similarity between the phrases, with a similarity of 1 of each phrase to
+//   itself
+
+func sparseSimilarity(phrases []string, rows []sparseRow, threshold float64, topK int) map[string]map[string]float64 {
+	// --------------------------------------------------------------------------------------------
+	// step 1: normalize the rows and index them by columns
+	postings := map[int][]phraseNeighbor{}
+	for i, row := range rows {
+		norm := 0.0
+		for _, value := range row.values {
+			norm += value * value
+		}
+		norm = math.Sqrt(norm)
+		for idx, c := range row.columns {
+			postings[c] = append(postings[c], phraseNeighbor{i, row.values[idx] / norm})
+		}
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 2: find the neighbors of each phrase on all the CPUs
+	numPhrases := len(phrases)
+	result := make([]map[string]float64, numPhrases)
+	chI := make(chan int)
+	var wg sync.WaitGroup
+	for idxCPU := 0; idxCPU < runtime.NumCPU(); idxCPU++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range chI {
+				norm := 0.0
+				for _, value := range rows[i].values {
+					norm += value * value
+				}
+				norm = math.Sqrt(norm)
+				dots := map[int]float64{}
+				for idx, c := range rows[i].columns {
+					for _, posting := range postings[c] {
+						if posting.index != i {
+							dots[posting.index] += rows[i].values[idx] / norm * posting.similarity
+						}
+					}
+				}
+				neighbors := []phraseNeighbor{}
+				for j, sim := range dots {
+					if sim >= threshold {
+						neighbors = append(neighbors, phraseNeighbor{j, sim})
+					}
+				}
+				result[i] = similarityRow(phrases, i, neighbors, topK)
+			}
+		}()
+	}
+	for i := 0; i < numPhrases; i++ {
+		chI <- i
+	}
+	close(chI)
+	wg.Wait()
+
+	similarity := make(map[string]map[string]float64, numPhrases)
+	for i, row := range result {
+		similarity[phrases[i]] = row
+	}
+	symmetrizeRows(similarity)
+	return similarity
+}
+
+// =================================================================================================
+// function CooccurrenceSimilarity
+// brief description:
+//   Build the phraseSimilarity of SimTF and SimIDF from the co-occurrences of the phrases of a
+//   corpus with their context words, without embeddings.
+// input:
+//   documents: the processed documents, whose candidates and their n-grams are compared
+//   options: the options of the similarity
+// output:
+//   a sparse matrix that gives the cosine similarity between the PPMI vectors of the stemmed
+//   phrases, or between their reductions by a truncated SVD, with a similarity of 1 of each phrase
+//   to itself
+// notes:
+//   The phrases without any positive PPMI are left out. The reference is:
+//   Levy, O., Goldberg, Y., & Dagan, I. (2015). Improving distributional similarity with lessons
+//   learned from word embeddings. Transactions of the ACL, 3, 211-225.
+
+func CooccurrenceSimilarity(documents []*Document, options CooccurrenceSimilarityOptions) map[string]map[string]float64 {
+	threshold := options.Threshold
+	if threshold == 0 {
+		threshold = 0.5
+	}
+	phrases, rows, numColumns := ppmiRows(countCooccurrences(documents, options))
+	if options.Dimensions <= 0 {
+		return sparseSimilarity(phrases, rows, threshold, options.TopK)
+	}
+	reduced := truncatedSVD(rows, numColumns, options.Dimensions, 1)
+	phraseVectors := make(map[string][]float64, len(phrases))
+	for i, phrase := range phrases {
+		phraseVectors[phrase] = reduced[i]
+	}
+	return similarityFromVectors(phraseVectors, threshold, options.TopK)
+}
@@ -0,0 +1,289 @@
+package KeyphraseExtraction
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Aggregation tells how the scores of a phrase in many documents are combined into a corpus score.
+type Aggregation int
+
+const (
+	// AggregateSum sums the scores of the phrase in the documents.
+	AggregateSum Aggregation = iota
+
+	// AggregateMean averages the scores of the phrase over the documents that have it.
+	AggregateMean
+
+	// AggregateCoverage takes the fraction of the documents that have the phrase.
+	AggregateCoverage
+
+	// AggregateRRF sums 1 / (RRFConstant + rank) over the documents that have the phrase, where rank
+	// is the rank of the phrase in the document, starting at 1.
+	AggregateRRF
+)
+
+// =================================================================================================
+// method Aggregation.String
+// brief description:
+//   Get the name of an aggregation.
+// output:
+//   "sum", "mean", "coverage" or "rrf".
+
+func (aggregation Aggregation) String() string {
+	switch aggregation {
+	case AggregateSum:
+		return "sum"
+	case AggregateMean:
+		return "mean"
+	case AggregateCoverage:
+		return "coverage"
+	case AggregateRRF:
+		return "rrf"
+	}
+	return fmt.Sprintf("Aggregation(%d)", int(aggregation))
+}
+
+// =================================================================================================
+// function ParseAggregation
+// brief description:
+//   Get the aggregation of a name returned by Aggregation.String.
+// input:
+//   name: The name of the aggregation.
+// output:
+//   The aggregation, and an error if the name is unknown.
+
+func ParseAggregation(name string) (Aggregation, error) {
+	for _, aggregation := range []Aggregation{AggregateSum, AggregateMean, AggregateCoverage, AggregateRRF} {
+		if aggregation.String() == name {
+			return aggregation, nil
+		}
+	}
+	return AggregateSum, fmt.Errorf("KeyphraseExtraction: unknown aggregation %q", name)
+}
+
+// ScoredDocument is a document of a corpus with the scores of its keyphrases.
+type ScoredDocument struct {
+	// ID identifies the document in the summary.
+	ID string
+
+	// Group is the value of the grouping field of the document, e.g. a product or a month.
+	Group string
+
+	// Scores is the score of each keyphrase of the document, higher is better.
+	Scores map[string]float64
+}
+
+// CorpusSummaryOptions controls a corpus summary.
+type CorpusSummaryOptions struct {
+	// Aggregation tells how the scores of a phrase in the documents are combined.
+	Aggregation Aggregation
+
+	// RRFConstant is the constant of AggregateRRF. 0 means 60.
+	RRFConstant float64
+
+	// MinDocuments is the smallest number of documents that a phrase must occur in.
+	MinDocuments int
+
+	// TopK is the largest number of phrases in a ranked list. 0 means no limit.
+	TopK int
+
+	// MaxSupporting is the largest number of supporting documents kept for a phrase, the ones where
+	// it scores best. 0 means no limit.
+	MaxSupporting int
+
+	// ByGroup also ranks the phrases of the documents of each group.
+	ByGroup bool
+}
+
+// SupportingDocument is a document that has a keyphrase of a corpus summary.
+type SupportingDocument struct {
+	// ID is the ID of the document.
+	ID string
+
+	// Score is the score of the phrase in the document.
+	Score float64
+
+	// Rank is the rank of the phrase in the document, starting at 1.
+	Rank int
+}
+
+// CorpusKeyphrase is a keyphrase of a corpus summary.
+type CorpusKeyphrase struct {
+	// Phrase is the keyphrase.
+	Phrase string
+
+	// Score is the aggregated score of the phrase.
+	Score float64
+
+	// NumDocuments is the number of documents that have the phrase.
+	NumDocuments int
+
+	// Documents are the documents that have the phrase, by decreasing score.
+	Documents []SupportingDocument
+}
+
+// CorpusSummary is the ranked keyphrases of a corpus.
+type CorpusSummary struct {
+	// Keyphrases are the keyphrases of the whole corpus, by decreasing score.
+	Keyphrases []CorpusKeyphrase
+
+	// Groups are the keyphrases of the documents of each group, if CorpusSummaryOptions.ByGroup is
+	// set.
+	Groups map[string][]CorpusKeyphrase
+}
+
+// =================================================================================================
+// function rankPhrases
+// brief description:
+//   Rank the phrases of a document by decreasing score, then alphabetically.
+// input:
+//   scores: the score of each phrase
+// output:
+//   the phrases, ranked
+
+func rankPhrases(scores map[string]float64) []string {
+	result := make([]string, 0, len(scores))
+	for phrase := range scores {
+		result = append(result, phrase)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if scores[result[i]] != scores[result[j]] {
+			return scores[result[i]] > scores[result[j]]
+		}
+		return result[i] < result[j]
+	})
+	return result
+}
+
+// =================================================================================================
+// function aggregateKeyphrases
+// brief description:
+//   Rank the keyphrases of some documents by their aggregated scores.
+// input:
+//   documents: the scored documents
+//   options: the options of the summary
+// output:
+//   the ranked keyphrases
+
+func aggregateKeyphrases(documents []ScoredDocument, options CorpusSummaryOptions) []CorpusKeyphrase {
+	// --------------------------------------------------------------------------------------------
+	// step 1: collect the supporting documents of each phrase
+	rrfConstant := options.RRFConstant
+	if rrfConstant <= 0 {
+		rrfConstant = 60.0
+	}
+	byPhrase := map[string]*CorpusKeyphrase{}
+	for _, document := range documents {
+		for idx, phrase := range rankPhrases(document.Scores) {
+			keyphrase, exists := byPhrase[phrase]
+			if !exists {
+				keyphrase = &CorpusKeyphrase{Phrase: phrase}
+				byPhrase[phrase] = keyphrase
+			}
+			score := document.Scores[phrase]
+			keyphrase.Documents = append(keyphrase.Documents, SupportingDocument{document.ID, score, idx + 1})
+			switch options.Aggregation {
+			case AggregateSum, AggregateMean:
+				keyphrase.Score += score
+			case AggregateRRF:
+				keyphrase.Score += 1.0 / (rrfConstant + float64(idx+1))
+			}
+		}
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 2: aggregate the scores and keep the best supporting documents
+	result := []CorpusKeyphrase{}
+	for _, keyphrase := range byPhrase {
+		keyphrase.NumDocuments = len(keyphrase.Documents)
+		if keyphrase.NumDocuments < options.MinDocuments {
+			continue
+		}
+		switch options.Aggregation {
+		case AggregateMean:
+			keyphrase.Score /= float64(keyphrase.NumDocuments)
+		case AggregateCoverage:
+			keyphrase.Score = float64(keyphrase.NumDocuments) / float64(len(documents))
+		}
+		supporting := keyphrase.Documents
+		sort.SliceStable(supporting, func(i, j int) bool {
+			return supporting[i].Score > supporting[j].Score
+		})
+		if options.MaxSupporting > 0 && len(supporting) > options.MaxSupporting {
+			keyphrase.Documents = supporting[:options.MaxSupporting]
+		}
+		result = append(result, *keyphrase)
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 3: rank the phrases
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Score != result[j].Score {
+			return result[i].Score > result[j].Score
+		}
+		return result[i].Phrase < result[j].Phrase
+	})
+	if options.TopK > 0 && len(result) > options.TopK {
+		result = result[:options.TopK]
+	}
+	return result
+}
+
+// =================================================================================================
+// function SummarizeCorpus
+// brief description:
+//   Rank the keyphrases of a corpus by aggregating their scores in its documents.
+// input:
+//   documents: the documents with the scores of their keyphrases, e.g. from CandidateScores
+//   options: the options of the summary
+// output:
+//   the keyphrases of the corpus, and of each group of documents if options.ByGroup is set, with
+//   their supporting documents
+// notes:
+//   AggregateRRF only uses the ranks of the phrases in the documents, so that it combines
+//   documents whose scores are not on the same scale. The reference is:
+//   Cormack, G. V., Clarke, C. L., & Buettcher, S. (2009). Reciprocal rank fusion outperforms
+//   Condorcet and individual rank learning methods. In SIGIR (pp. 758-759).
+
+func SummarizeCorpus(documents []ScoredDocument, options CorpusSummaryOptions) CorpusSummary {
+	result := CorpusSummary{Keyphrases: aggregateKeyphrases(documents, options)}
+	if !options.ByGroup {
+		return result
+	}
+	groups := map[string][]ScoredDocument{}
+	for _, document := range documents {
+		groups[document.Group] = append(groups[document.Group], document)
+	}
+	result.Groups = make(map[string][]CorpusKeyphrase, len(groups))
+	for group, groupDocuments := range groups {
+		result.Groups[group] = aggregateKeyphrases(groupDocuments, options)
+	}
+	return result
+}
+
+// =================================================================================================
+// function CandidateScores
+// brief description:
+//   Score the key phrase candidates of each document of a corpus with TF-IDF.
+// input:
+//   phraseCandidateGroups: the key phrase candidates of each document, e.g. from
+//                          ExtractKeyPhraseCandidates
+// output:
+//   the TF-IDF of each distinct candidate of each document, where TF counts the candidates of the
+//   document and IDF is computed over the corpus
+
+func CandidateScores(phraseCandidateGroups [][]string) []map[string]float64 {
+	idf := IDF(phraseCandidateGroups)
+	result := make([]map[string]float64, len(phraseCandidateGroups))
+	for i, candidates := range phraseCandidateGroups {
+		tf := TF(candidates, candidates)
+		result[i] = make(map[string]float64, len(candidates))
+		for _, candidate := range candidates {
+			if freq, exists := tf[candidate]; exists {
+				result[i][candidate] = float64(freq) * idf[candidate]
+			}
+		}
+	}
+	return result
+}
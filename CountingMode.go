@@ -0,0 +1,185 @@
+package KeyphraseExtraction
+
+import "fmt"
+
+// CountingMode tells which n-grams are counted by TF and IDF, and which occurrences are credited to
+// them.
+type CountingMode int
+
+const (
+	// CountNested counts every n-gram of every candidate, so that "network" is credited with the
+	// occurrences of "neural network".
+	CountNested CountingMode = iota
+
+	// CountExact counts the candidates only, each credited when an auxiliary phrase is exactly the
+	// candidate.
+	CountExact
+
+	// CountMaximal counts the candidates only, each span of an auxiliary phrase credited to the
+	// longest candidate that matches there, from left to right.
+	CountMaximal
+)
+
+// =================================================================================================
+// method CountingMode.String
+// brief description:
+//   Get the name of a counting mode, as recorded with a model.
+// output:
+//   "nested", "exact" or "maximal".
+
+func (mode CountingMode) String() string {
+	switch mode {
+	case CountNested:
+		return "nested"
+	case CountExact:
+		return "exact"
+	case CountMaximal:
+		return "maximal"
+	}
+	return fmt.Sprintf("CountingMode(%d)", int(mode))
+}
+
+// =================================================================================================
+// function ParseCountingMode
+// brief description:
+//   Get the counting mode of a name returned by CountingMode.String.
+// input:
+//   name: The name of the counting mode.
+// output:
+//   The counting mode, and an error if the name is unknown.
+
+func ParseCountingMode(name string) (CountingMode, error) {
+	for _, mode := range []CountingMode{CountNested, CountExact, CountMaximal} {
+		if mode.String() == name {
+			return mode, nil
+		}
+	}
+	return CountNested, fmt.Errorf("KeyphraseExtraction: unknown counting mode %q", name)
+}
+
+// =================================================================================================
+// function countedKey
+// brief description:
+//   Get the key of a whole candidate, which is counted by CountExact and CountMaximal.
+// input:
+//   ids: The word IDs of the candidate.
+//   maxNGramLength: The maximum number of words of a counted candidate, or 0 for no limit.
+// output:
+//   The key of the candidate, and false if it is empty or longer than maxNGramLength.
+
+func countedKey(ids []WordID, maxNGramLength int) (NGramKey, bool) {
+	if len(ids) == 0 || maxNGramLength > 0 && len(ids) > maxNGramLength {
+		return 0, false
+	}
+	return NGramKeyOf(ids), true
+}
+
+// =================================================================================================
+// function countedNGramKeys
+// brief description:
+//   Find the set of n-grams of a group of key phrase candidates that are counted in a mode.
+// input:
+//   vocabulary: The vocabulary that encodes the candidates.
+//   candidates: A group of key phrase candidates.
+//   mode: The counting mode.
+//   maxNGramLength: The maximum number of words of an n-gram, or 0 for no limit.
+//   nGrams: The map that receives the word IDs of each n-gram by its key.
+
+func countedNGramKeys(vocabulary *Vocabulary, candidates []string, mode CountingMode, maxNGramLength int,
+	nGrams map[NGramKey][]WordID) {
+	if mode == CountNested {
+		candidateNGramKeys(vocabulary, candidates, maxNGramLength, nGrams)
+		return
+	}
+	for _, candidate := range candidates {
+		ids := vocabulary.Encode(candidate)
+		if key, counted := countedKey(ids, maxNGramLength); counted {
+			nGrams[key] = ids
+		}
+	}
+}
+
+// =================================================================================================
+// function CountTFWithMode
+// brief description:
+//   Compute Term Frequencies for a set of key phrase candidates with a set of auxiliary phrases, on
+//   word IDs, in a counting mode. See TFWithMode.
+// input:
+//   candidates: The word IDs of the key phrase candidates.
+//   auxPhrases: The word IDs of the auxiliary phrases.
+//   mode: The counting mode.
+//   maxNGramLength: The maximum number of words of the n-grams counted, or 0 for no limit.
+// output:
+//   The term frequency of each counted n-gram, by its key.
+
+func CountTFWithMode(candidates [][]WordID, auxPhrases [][]WordID, mode CountingMode,
+	maxNGramLength int) map[NGramKey]uint {
+	// --------------------------------------------------------------------------------------------
+	// step 1: the nested mode counts all the n-grams
+	if mode == CountNested {
+		return CountTF(candidates, auxPhrases, maxNGramLength)
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 2: initialize the result with the whole candidates
+	result := map[NGramKey]uint{}
+	trie := NewPhraseTrie(nil)
+	for _, candidate := range candidates {
+		if key, counted := countedKey(candidate, maxNGramLength); counted {
+			result[key] = 0
+			trie.InsertIDs(candidate)
+		}
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 3: scan through auxPhrases and credit the matched candidates
+	for _, auxPhrase := range auxPhrases {
+		if mode == CountExact {
+			if key, counted := countedKey(auxPhrase, maxNGramLength); counted {
+				if oldFreq, exists := result[key]; exists {
+					result[key] = oldFreq + 1
+				}
+			}
+			continue
+		}
+		for begin := 0; begin < len(auxPhrase); {
+			end := trie.LongestMatch(auxPhrase, begin)
+			if end == begin {
+				begin++
+				continue
+			}
+			result[NGramKeyOf(auxPhrase[begin:end])]++
+			begin = end
+		}
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 4: return the result
+	return result
+}
+
+// =================================================================================================
+// method Vocabulary.countedTexts
+// brief description:
+//   Build the texts of the n-grams of some phrases that are counted in a mode, each distinct n-gram
+//   once.
+// input:
+//   phrases: The IDs of the words of the phrases.
+//   mode: The counting mode.
+//   maxNGramLength: The maximum number of words of an n-gram, or 0 for no limit.
+//   texts: The map that receives the texts of the n-grams by their keys.
+
+func (vocabulary *Vocabulary) countedTexts(phrases [][]WordID, mode CountingMode, maxNGramLength int,
+	texts map[NGramKey]string) {
+	if mode == CountNested {
+		vocabulary.nGramTexts(phrases, maxNGramLength, texts)
+		return
+	}
+	for _, phrase := range phrases {
+		if key, counted := countedKey(phrase, maxNGramLength); counted {
+			if _, exists := texts[key]; !exists {
+				texts[key] = vocabulary.Decode(phrase)
+			}
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package KeyphraseExtraction
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// dfModelHeader is the first line of a document frequency model written by DFCounter.WriteTo.
+const dfModelHeader = "KeyphraseExtraction document frequencies v1"
+
+// DFCounter counts the document frequencies of the n-grams of key phrase candidates one document at
+// a time, so that IDF can be computed without holding the candidates of a whole corpus. It is safe
+// for concurrent use.
+type DFCounter struct {
+	mutex             sync.Mutex
+	vocabulary        *Vocabulary
+	mode              CountingMode
+	maxNGramLength    int
+	numDocuments      int
+	documentFrequency map[NGramKey]float64
+	texts             map[NGramKey]string
+}
+
+// =================================================================================================
+// function NewDFCounter
+// brief description:
+//   Create an empty document frequency counter.
+// output:
+//   The new counter.
+
+func NewDFCounter() *DFCounter {
+	return NewDFCounterWithMode(CountNested, 0)
+}
+
+// =================================================================================================
+// function NewDFCounterWithMode
+// brief description:
+//   Create an empty document frequency counter that counts in a mode. See IDFWithMode.
+// input:
+//   mode: which n-grams are counted
+//   maxNGramLength: the maximum number of words of the n-grams counted, or 0 for no limit. It is
+//                   not written by WriteTo.
+// output:
+//   The new counter.
+
+func NewDFCounterWithMode(mode CountingMode, maxNGramLength int) *DFCounter {
+	return &DFCounter{
+		vocabulary:        NewVocabulary(),
+		mode:              mode,
+		maxNGramLength:    maxNGramLength,
+		documentFrequency: map[NGramKey]float64{},
+		texts:             map[NGramKey]string{},
+	}
+}
+
+// =================================================================================================
+// method DFCounter.Mode
+// brief description:
+//   Get the counting mode of the counter.
+// output:
+//   the counting mode
+
+func (counter *DFCounter) Mode() CountingMode {
+	counter.mutex.Lock()
+	defer counter.mutex.Unlock()
+	return counter.mode
+}
+
+// =================================================================================================
+// method DFCounter.Add
+// brief description:
+//   Count the n-grams of the key phrase candidates of a document.
+// input:
+//   candidates: the key phrase candidates of the document
+
+func (counter *DFCounter) Add(candidates []string) {
+	counter.mutex.Lock()
+	vocabulary, mode := counter.vocabulary, counter.mode
+	counter.mutex.Unlock()
+	nGrams := map[NGramKey][]WordID{}
+	countedNGramKeys(vocabulary, candidates, mode, counter.maxNGramLength, nGrams)
+
+	counter.mutex.Lock()
+	defer counter.mutex.Unlock()
+	if counter.vocabulary != vocabulary || counter.mode != mode {
+		// the counter has been replaced by ReadFrom in the meantime
+		nGrams = map[NGramKey][]WordID{}
+		countedNGramKeys(counter.vocabulary, candidates, counter.mode, counter.maxNGramLength, nGrams)
+	}
+	counter.numDocuments++
+	for key, ids := range nGrams {
+		if _, exists := counter.texts[key]; !exists {
+			counter.texts[key] = counter.vocabulary.Decode(ids)
+		}
+		counter.documentFrequency[key] += 1.0
+	}
+}
+
+// =================================================================================================
+// method DFCounter.AddDocument
+// brief description:
+//   Count the n-grams of the key phrase candidates of a processed document.
+// input:
+//   document: the processed document
+
+func (counter *DFCounter) AddDocument(document *Document) {
+	counter.Add(document.CandidatePhrases())
+}
+
+// =================================================================================================
+// method DFCounter.NumDocuments
+// brief description:
+//   Get the number of documents counted.
+// output:
+//   the number of documents
+
+func (counter *DFCounter) NumDocuments() int {
+	counter.mutex.Lock()
+	defer counter.mutex.Unlock()
+	return counter.numDocuments
+}
+
+// =================================================================================================
+// method DFCounter.DocumentFrequencies
+// brief description:
+//   Get the document frequencies counted so far.
+// output:
+//   a copy of the document frequencies
+
+func (counter *DFCounter) DocumentFrequencies() map[string]float64 {
+	counter.mutex.Lock()
+	defer counter.mutex.Unlock()
+	result := make(map[string]float64, len(counter.documentFrequency))
+	for key, df := range counter.documentFrequency {
+		result[counter.texts[key]] = df
+	}
+	return result
+}
+
+// =================================================================================================
+// method DFCounter.IDF
+// brief description:
+//   Compute the inverse document frequencies from the documents counted so far, as IDF does.
+// output:
+//   the inverse document frequencies
+
+func (counter *DFCounter) IDF() map[string]float64 {
+	counter.mutex.Lock()
+	defer counter.mutex.Unlock()
+	n := float64(counter.numDocuments)
+	result := make(map[string]float64, len(counter.documentFrequency))
+	for key, df := range counter.documentFrequency {
+		result[counter.texts[key]] = math.Log(n / df)
+	}
+	return result
+}
+
+// =================================================================================================
+// method DFCounter.WriteTo
+// brief description:
+//   Write the counting mode, the number of documents and the document frequencies, as a model
+//   that can be read back with ReadFrom.
+// input:
+//   writer: the writer
+// output:
+//   the number of bytes written, and the error if any
+
+func (counter *DFCounter) WriteTo(writer io.Writer) (int64, error) {
+	counter.mutex.Lock()
+	defer counter.mutex.Unlock()
+	buffered := bufio.NewWriter(writer)
+	var result int64
+	n, err := fmt.Fprintf(buffered, "%s\nmode\t%s\ndocuments\t%d\n", dfModelHeader, counter.mode,
+		counter.numDocuments)
+	result += int64(n)
+	if err != nil {
+		return result, err
+	}
+	// the phrases are sorted, so that the same counts are always written the same way
+	keys := make([]NGramKey, 0, len(counter.documentFrequency))
+	for key := range counter.documentFrequency {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return counter.texts[keys[i]] < counter.texts[keys[j]] })
+	for _, key := range keys {
+		df := counter.documentFrequency[key]
+		n, err = fmt.Fprintf(buffered, "%s\t%s\n", strconv.FormatFloat(df, 'g', -1, 64), counter.texts[key])
+		result += int64(n)
+		if err != nil {
+			return result, err
+		}
+	}
+	return result, buffered.Flush()
+}
+
+// =================================================================================================
+// method DFCounter.ReadFrom
+// brief description:
+//   Replace the content of the counter with a model written by WriteTo, counting mode included.
+// input:
+//   reader: the reader
+// output:
+//   the number of bytes read, and the error if any
+
+func (counter *DFCounter) ReadFrom(reader io.Reader) (int64, error) {
+	// --------------------------------------------------------------------------------------------
+	// step 1: read the header, the counting mode and the number of documents
+	input := &countingReader{reader: bufio.NewReader(reader)}
+	scanner := bufio.NewScanner(input)
+	lines := []string{}
+	for len(lines) < 3 && scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return input.count, err
+	}
+	if len(lines) < 3 || lines[0] != dfModelHeader ||
+		!strings.HasPrefix(lines[1], "mode\t") || !strings.HasPrefix(lines[2], "documents\t") {
+		return input.count, fmt.Errorf("KeyphraseExtraction: not a document frequency model")
+	}
+	mode, err := ParseCountingMode(strings.TrimPrefix(lines[1], "mode\t"))
+	if err != nil {
+		return input.count, err
+	}
+	numDocuments, err := strconv.Atoi(strings.TrimPrefix(lines[2], "documents\t"))
+	if err != nil {
+		return input.count, fmt.Errorf("KeyphraseExtraction: document frequency model: %v", err)
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 2: read the document frequencies
+	vocabulary := NewVocabulary()
+	documentFrequency := map[NGramKey]float64{}
+	texts := map[NGramKey]string{}
+	for lineNumber := 4; scanner.Scan(); lineNumber++ {
+		line := scanner.Text()
+		fields := strings.SplitN(line, "\t", 2)
+		if len(fields) != 2 {
+			return input.count, fmt.Errorf("KeyphraseExtraction: document frequency model line %d: expected frequency and phrase", lineNumber)
+		}
+		df, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return input.count, fmt.Errorf("KeyphraseExtraction: document frequency model line %d: %v", lineNumber, err)
+		}
+		key := NGramKeyOf(vocabulary.Encode(fields[1]))
+		documentFrequency[key] = df
+		texts[key] = fields[1]
+	}
+	if err := scanner.Err(); err != nil {
+		return input.count, err
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 3: replace the content of the counter
+	counter.mutex.Lock()
+	defer counter.mutex.Unlock()
+	counter.vocabulary = vocabulary
+	counter.mode = mode
+	counter.numDocuments = numDocuments
+	counter.documentFrequency = documentFrequency
+	counter.texts = texts
+	return input.count, nil
+}
@@ -0,0 +1,48 @@
+package KeyphraseExtraction
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDFCounterRoundTrip(t *testing.T) {
+	counter := NewDFCounter()
+	counter.Add([]string{"neural network", "graph"})
+	counter.Add([]string{"deep neural network", "language model"})
+	counter.Add([]string{"graph", "network"})
+
+	var first, second bytes.Buffer
+	written, err := counter.WriteTo(&first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written != int64(first.Len()) {
+		t.Errorf("WriteTo returned %d, wrote %d bytes", written, first.Len())
+	}
+	counter.WriteTo(&second)
+	if first.String() != second.String() {
+		t.Errorf("WriteTo is not deterministic:\n%s\n%s", first.String(), second.String())
+	}
+	lines := strings.Split(strings.TrimSuffix(first.String(), "\n"), "\n")[3:]
+	for i := 1; i < len(lines); i++ {
+		if strings.SplitN(lines[i-1], "\t", 2)[1] >= strings.SplitN(lines[i], "\t", 2)[1] {
+			t.Errorf("phrases are not sorted: %q before %q", lines[i-1], lines[i])
+		}
+	}
+
+	// a model with Windows line endings
+	for _, model := range []string{first.String(), strings.ReplaceAll(first.String(), "\n", "\r\n")} {
+		loaded := NewDFCounter()
+		read, err := loaded.ReadFrom(strings.NewReader(model))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if read != int64(len(model)) {
+			t.Errorf("ReadFrom returned %d, want %d", read, len(model))
+		}
+		if loaded.NumDocuments() != 3 || len(loaded.DocumentFrequencies()) != len(counter.DocumentFrequencies()) {
+			t.Errorf("loaded %d documents and %d phrases", loaded.NumDocuments(), len(loaded.DocumentFrequencies()))
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package KeyphraseExtraction
+
+import "strings"
+
+// Token is a word of a document, as it has been processed by the pipeline.
+type Token struct {
+	// Text is the original text of the token, and Offset is its byte offset in the document. The
+	// tokens made from a single original token, e.g. the words of an expanded acronym, share them.
+	Text   string
+	Offset int
+
+	// Normalized is the token after the conversions and case folding, and Stem is its stem.
+	Normalized string
+	Stem       string
+
+	// POS is the part of speech of the token, or "" if the tokens have not been tagged.
+	POS string
+
+	// Sentence is the index of the sentence of the token.
+	Sentence int
+}
+
+// Sentence is a sentence of a document, which holds the tokens [Begin, End).
+type Sentence struct {
+	Text   string
+	Offset int
+	Begin  int
+	End    int
+}
+
+// Candidate is a key phrase candidate of a document, which is made of the tokens [Begin, End).
+type Candidate struct {
+	// Phrase is the stemmed phrase, as returned by ExtractKeyPhraseCandidates.
+	Phrase   string
+	Sentence int
+	Begin    int
+	End      int
+}
+
+// Document is a text processed by the pipeline, which keeps its sentences, tokens and candidates.
+type Document struct {
+	Text        string
+	Sentences   []Sentence
+	Tokens      []Token
+	Candidates  []Candidate
+	Conversions []Conversion
+}
+
+// =================================================================================================
+// method Document.CandidatePhrases
+// brief description:
+//   Get the stemmed phrases of the candidates of the document.
+// output:
+//   A vector of the stems of the key phrase candidates.
+
+func (document *Document) CandidatePhrases() []string {
+	result := make([]string, len(document.Candidates))
+	for i, candidate := range document.Candidates {
+		result[i] = candidate.Phrase
+	}
+	return result
+}
+
+// =================================================================================================
+// method Document.SentenceCandidates
+// brief description:
+//   Group the candidates of the document by sentences.
+// output:
+//   For each sentence, the stemmed phrases of its candidates.
+
+func (document *Document) SentenceCandidates() [][]string {
+	result := make([][]string, len(document.Sentences))
+	for _, candidate := range document.Candidates {
+		result[candidate.Sentence] = append(result[candidate.Sentence], candidate.Phrase)
+	}
+	return result
+}
+
+// =================================================================================================
+// method Document.SurfaceForm
+// brief description:
+//   Get the original text of a candidate.
+// input:
+//   candidate: A candidate of the document.
+// output:
+//   The text of the document covered by the tokens of the candidate.
+
+func (document *Document) SurfaceForm(candidate Candidate) string {
+	if candidate.Begin >= candidate.End {
+		return ""
+	}
+	first := document.Tokens[candidate.Begin]
+	last := document.Tokens[candidate.End-1]
+	return document.Text[first.Offset : last.Offset+len(last.Text)]
+}
+
+// =================================================================================================
+// method Document.isConverted
+// brief description:
+//   Tell whether a token has been rewritten by a conversion of the pipeline.
+// input:
+//   tok: A token of the document.
+// output:
+//   True if a conversion covers the original text of the token.
+
+func (document *Document) isConverted(tok Token) bool {
+	for _, conversion := range document.Conversions {
+		if conversion.Begin <= tok.Offset && tok.Offset+len(tok.Text) <= conversion.End {
+			return true
+		}
+	}
+	return false
+}
+
+// =================================================================================================
+// method Document.RestoreSurfaceForm
+// brief description:
+//   Undo the conversions made by the pipeline in a candidate, e.g. restore "world war 2" to
+//   "world war II", keeping the other words as they are in the stemmed phrase.
+// input:
+//   candidate: A candidate of the document.
+// output:
+//   The phrase of the candidate, in which the words that come from converted tokens are replaced
+//   by the original text of the tokens.
+// notes:
+//   Only the tokens covered by a conversion are restored, so that a "2" that comes from "2nd" or
+//   from a plain "2" elsewhere in the text is not restored to "II". A token rewritten into several
+//   words, e.g. an expanded acronym, is restored once, if all its words are in the candidate.
+
+func (document *Document) RestoreSurfaceForm(candidate Candidate) string {
+	// --------------------------------------------------------------------------------------------
+	// step 1: find the words of the phrase that come from each token
+	tokens := document.Tokens[candidate.Begin:candidate.End]
+	converted := false
+	for _, tok := range tokens {
+		converted = converted || document.isConverted(tok)
+	}
+	if !converted {
+		return candidate.Phrase
+	}
+	phraseWords := strings.Split(candidate.Phrase, " ")
+	tokenWords := make([][]string, len(tokens))
+	numWords := 0
+	for i, tok := range tokens {
+		tokenWords[i] = strings.Split(tok.Stem, " ")
+		numWords += len(tokenWords[i])
+	}
+	if numWords == len(phraseWords) {
+		// the words of the phrase itself, as the hyphen variant of the phrase may differ
+		begin := 0
+		for i := range tokenWords {
+			tokenWords[i], begin = phraseWords[begin:begin+len(tokenWords[i])], begin+len(tokenWords[i])
+		}
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 2: replace the words of each converted original token with its text
+	result := []string{}
+	for i := 0; i < len(tokens); {
+		// the tokens made from the same original token
+		j := i + 1
+		for j < len(tokens) && tokens[j].Offset == tokens[i].Offset {
+			j++
+		}
+		first, last := candidate.Begin+i, candidate.Begin+j
+		whole := (first == 0 || document.Tokens[first-1].Offset != tokens[i].Offset) &&
+			(last == len(document.Tokens) || document.Tokens[last].Offset != tokens[i].Offset)
+		if whole && document.isConverted(tokens[i]) {
+			result = append(result, tokens[i].Text)
+		} else {
+			for _, words := range tokenWords[i:j] {
+				result = append(result, words...)
+			}
+		}
+		i = j
+	}
+	return strings.Join(result, " ")
+}
@@ -0,0 +1,299 @@
+package KeyphraseExtraction
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// DocumentVectorOptions controls the vectors of the documents of a DocumentCollection.
+type DocumentVectorOptions struct {
+	// Similarity weights the documents with SimTF and SimIDF instead of TF and IDF. nil means TF and
+	// IDF.
+	Similarity SimilaritySource
+
+	// Mode tells which n-grams are counted by TF and IDF, as in TFWithMode. It is ignored with
+	// Similarity, as SimTF and SimIDF count every n-gram.
+	Mode CountingMode
+
+	// MaxNGramLength is the maximum number of words of the n-grams counted, as in TFWithMode. 0
+	// means no limit.
+	MaxNGramLength int
+}
+
+// SharedKeyphrase is a keyphrase of two similar documents.
+type SharedKeyphrase struct {
+	// Phrase is the stemmed phrase.
+	Phrase string
+
+	// Contribution is the part of the cosine similarity of the documents that comes from the
+	// phrase.
+	Contribution float64
+}
+
+// SimilarDocument is a document similar to a query.
+type SimilarDocument struct {
+	// ID is the ID of the document.
+	ID string
+
+	// Similarity is the cosine similarity between the document and the query.
+	Similarity float64
+
+	// Shared are the keyphrases of both the document and the query, by decreasing contribution.
+	Shared []SharedKeyphrase
+}
+
+// DocumentCollection holds the sparse keyphrase vectors of some documents, so that they can be
+// compared. Its methods are safe for concurrent use, as long as its similarity source is safe for
+// concurrent reads, as SimilarityMap and SimilarityMatrix are.
+type DocumentCollection struct {
+	options DocumentVectorOptions
+	ids     []string
+	index   map[string]int
+
+	// idf is the (fuzzy) inverse document frequency of each phrase of the collection
+	idf map[string]float64
+
+	// vectors are the unit TF-IDF vectors of the documents, and postings the documents of each
+	// phrase with its weight in their vectors
+	vectors  []map[string]float64
+	postings map[string][]phraseNeighbor
+}
+
+// =================================================================================================
+// function NewDocumentCollection
+// brief description:
+//   Build the sparse TF-IDF or Sim-TF-IDF vectors of some documents.
+// input:
+//   ids: the ID of each document
+//   phraseCandidateGroups: the key phrase candidates of each document, e.g. from
+//                          ExtractKeyPhraseCandidates
+//   options: the options of the vectors
+// output:
+//   the collection, and an error if the IDs are not unique or do not match the documents
+
+func NewDocumentCollection(ids []string, phraseCandidateGroups [][]string,
+	options DocumentVectorOptions) (*DocumentCollection, error) {
+	// --------------------------------------------------------------------------------------------
+	// step 1: check the IDs
+	if len(ids) != len(phraseCandidateGroups) {
+		return nil, fmt.Errorf("KeyphraseExtraction: %d document IDs for %d documents", len(ids), len(phraseCandidateGroups))
+	}
+	collection := &DocumentCollection{
+		options:  options,
+		ids:      append([]string(nil), ids...),
+		index:    make(map[string]int, len(ids)),
+		vectors:  make([]map[string]float64, len(ids)),
+		postings: map[string][]phraseNeighbor{},
+	}
+	for i, id := range ids {
+		if _, exists := collection.index[id]; exists {
+			return nil, fmt.Errorf("KeyphraseExtraction: duplicate document ID %q", id)
+		}
+		collection.index[id] = i
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 2: weight the documents and index their phrases
+	if options.Similarity != nil {
+		collection.idf = SimIDFWith(phraseCandidateGroups, options.Similarity, options.MaxNGramLength)
+	} else {
+		collection.idf = IDFWithMode(phraseCandidateGroups, options.Mode, options.MaxNGramLength)
+	}
+	for i, candidates := range phraseCandidateGroups {
+		collection.vectors[i] = collection.vectorize(candidates)
+		for phrase, weight := range collection.vectors[i] {
+			collection.postings[phrase] = append(collection.postings[phrase], phraseNeighbor{i, weight})
+		}
+	}
+	return collection, nil
+}
+
+// =================================================================================================
+// method DocumentCollection.vectorize
+// brief description:
+//   Build the unit vector of the candidates of a document with the IDF of the collection.
+// input:
+//   candidates: the key phrase candidates of the document
+// output:
+//   the weight of each phrase with a positive IDF, normalized to a unit vector
+
+func (collection *DocumentCollection) vectorize(candidates []string) map[string]float64 {
+	result := map[string]float64{}
+	maxNGramLength := collection.options.MaxNGramLength
+	if collection.options.Similarity != nil {
+		for phrase, tf := range SimTFWith(candidates, candidates, collection.options.Similarity, maxNGramLength) {
+			result[phrase] = tf * collection.idf[phrase]
+		}
+	} else {
+		for phrase, tf := range TFWithMode(candidates, candidates, collection.options.Mode, maxNGramLength) {
+			result[phrase] = float64(tf) * collection.idf[phrase]
+		}
+	}
+	norm := 0.0
+	for phrase, weight := range result {
+		if weight <= 0 {
+			delete(result, phrase)
+			continue
+		}
+		norm += weight * weight
+	}
+	norm = math.Sqrt(norm)
+	for phrase := range result {
+		result[phrase] /= norm
+	}
+	return result
+}
+
+// =================================================================================================
+// method DocumentCollection.Len
+// brief description:
+//   Get the number of documents of the collection.
+// output:
+//   the number of documents
+
+func (collection *DocumentCollection) Len() int {
+	return len(collection.ids)
+}
+
+// =================================================================================================
+// method DocumentCollection.Vector
+// brief description:
+//   Get the vector of a document.
+// input:
+//   id: the ID of the document
+// output:
+//   the unit TF-IDF vector of the document, which must not be modified, or nil if the document is
+//   not in the collection
+
+func (collection *DocumentCollection) Vector(id string) map[string]float64 {
+	i, exists := collection.index[id]
+	if !exists {
+		return nil
+	}
+	return collection.vectors[i]
+}
+
+// =================================================================================================
+// function sharedKeyphrases
+// brief description:
+//   Find the keyphrases of two document vectors and their contributions to their cosine.
+// input:
+//   vector1, vector2: the unit vectors of the documents
+// output:
+//   the cosine similarity, and the shared keyphrases by decreasing contribution
+
+func sharedKeyphrases(vector1, vector2 map[string]float64) (float64, []SharedKeyphrase) {
+	if len(vector2) < len(vector1) {
+		vector1, vector2 = vector2, vector1
+	}
+	similarity := 0.0
+	shared := []SharedKeyphrase{}
+	for phrase, weight := range vector1 {
+		if other, exists := vector2[phrase]; exists {
+			similarity += weight * other
+			shared = append(shared, SharedKeyphrase{phrase, weight * other})
+		}
+	}
+	sort.Slice(shared, func(i, j int) bool {
+		if shared[i].Contribution != shared[j].Contribution {
+			return shared[i].Contribution > shared[j].Contribution
+		}
+		return shared[i].Phrase < shared[j].Phrase
+	})
+	return similarity, shared
+}
+
+// =================================================================================================
+// method DocumentCollection.Similarity
+// brief description:
+//   Compute the cosine similarity between two documents of the collection.
+// input:
+//   id1, id2: the IDs of the documents
+// output:
+//   the cosine similarity, 0 if a document is not in the collection
+
+func (collection *DocumentCollection) Similarity(id1, id2 string) float64 {
+	similarity, _ := sharedKeyphrases(collection.Vector(id1), collection.Vector(id2))
+	return similarity
+}
+
+// =================================================================================================
+// method DocumentCollection.similarDocuments
+// brief description:
+//   Find the documents most similar to a vector.
+// input:
+//   vector: the unit vector of the query
+//   exclude: the index of a document left out, or -1
+//   k: the largest number of documents returned, or 0 for no limit
+// output:
+//   the documents that share a keyphrase with the query, by decreasing similarity, then by IDs
+
+func (collection *DocumentCollection) similarDocuments(vector map[string]float64, exclude, k int) []SimilarDocument {
+	// --------------------------------------------------------------------------------------------
+	// step 1: accumulate the dot products through the postings
+	dots := map[int]float64{}
+	for phrase, weight := range vector {
+		for _, posting := range collection.postings[phrase] {
+			if posting.index != exclude {
+				dots[posting.index] += weight * posting.similarity
+			}
+		}
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 2: rank the documents, then explain the top ones
+	order := make([]int, 0, len(dots))
+	for i := range dots {
+		order = append(order, i)
+	}
+	sort.Slice(order, func(a, b int) bool {
+		if dots[order[a]] != dots[order[b]] {
+			return dots[order[a]] > dots[order[b]]
+		}
+		return collection.ids[order[a]] < collection.ids[order[b]]
+	})
+	if k > 0 && len(order) > k {
+		order = order[:k]
+	}
+	result := make([]SimilarDocument, len(order))
+	for rank, i := range order {
+		_, shared := sharedKeyphrases(vector, collection.vectors[i])
+		result[rank] = SimilarDocument{collection.ids[i], dots[i], shared}
+	}
+	return result
+}
+
+// =================================================================================================
+// method DocumentCollection.MoreLikeThis
+// brief description:
+//   Find the documents of the collection most similar to one of them.
+// input:
+//   id: the ID of the document
+//   k: the largest number of documents returned, or 0 for no limit
+// output:
+//   the similar documents, the document itself excluded, with the keyphrases that explain them,
+//   and an error if the document is not in the collection
+
+func (collection *DocumentCollection) MoreLikeThis(id string, k int) ([]SimilarDocument, error) {
+	i, exists := collection.index[id]
+	if !exists {
+		return nil, fmt.Errorf("KeyphraseExtraction: unknown document ID %q", id)
+	}
+	return collection.similarDocuments(collection.vectors[i], i, k), nil
+}
+
+// =================================================================================================
+// method DocumentCollection.MoreLikeCandidates
+// brief description:
+//   Find the documents of the collection most similar to a new document.
+// input:
+//   candidates: the key phrase candidates of the new document, weighted with the IDF of the
+//               collection
+//   k: the largest number of documents returned, or 0 for no limit
+// output:
+//   the similar documents, with the keyphrases that explain them
+
+func (collection *DocumentCollection) MoreLikeCandidates(candidates []string, k int) []SimilarDocument {
+	return collection.similarDocuments(collection.vectorize(candidates), -1, k)
+}
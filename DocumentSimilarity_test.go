@@ -0,0 +1,53 @@
+package KeyphraseExtraction
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestDocumentCollectionCopiesIDs(t *testing.T) {
+	ids := []string{"a", "b", "c"}
+	collection, err := NewDocumentCollection(ids,
+		[][]string{{"neural network"}, {"neural network", "graph"}, {"language model"}}, DocumentVectorOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids[0] = "c"
+	similar, err := collection.MoreLikeThis("b", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(similar) != 1 || similar[0].ID != "a" {
+		t.Errorf("MoreLikeThis(\"b\") = %+v, want document a", similar)
+	}
+}
+
+func TestDocumentCollectionConcurrentReads(t *testing.T) {
+	// run with -race: the matrix changed after the collection is built is compressed by the first
+	// reads
+	matrix := NewSimilarityMatrix()
+	matrix.Set("neural network", "neural net", 0.8)
+	matrix.Set("graph", "network graph", 0.6)
+	groups := [][]string{
+		{"neural network", "graph"},
+		{"neural net", "deep learning"},
+		{"network graph", "language model"},
+	}
+	collection, err := NewDocumentCollection([]string{"a", "b", "c"}, groups,
+		DocumentVectorOptions{Similarity: matrix})
+	if err != nil {
+		t.Fatal(err)
+	}
+	matrix.Set("deep learning", "neural net", 0.5)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			collection.MoreLikeCandidates([]string{"neural network", "graph"}, 2)
+			collection.MoreLikeThis("a", 0)
+			collection.Similarity("a", "b")
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,72 @@
+package KeyphraseExtraction
+
+import "testing"
+
+// acronymExtractor expands the acronyms, which the default extractor does not
+var acronymExtractor = NewExtractor(Options{ExpandAcronyms: true})
+
+func restoredForms(text string) map[string]bool {
+	document := acronymExtractor.ExtractDocument(text)
+	result := map[string]bool{}
+	for _, candidate := range document.Candidates {
+		result[document.RestoreSurfaceForm(candidate)] = true
+	}
+	return result
+}
+
+func TestRestoreSurfaceForm(t *testing.T) {
+	stem := func(text string) string { return StemPhrases([]string{text})[0] }
+	for _, test := range []struct {
+		text    string
+		want    []string
+		notWant []string
+	}{
+		// a "2" elsewhere in the text is not the roman number
+		{"Type II diabetes. We split 2 groups.", []string{stem("type") + " II " + stem("diabetes"), stem("split 2 groups")},
+			[]string{stem("split") + " II " + stem("groups")}},
+		// a "2" that comes from "2nd" is restored to "2nd"
+		{"World War II ended. The 2nd edition.", []string{stem("world war") + " II " + stem("ended"), "2nd " + stem("edition")},
+			[]string{"II " + stem("edition")}},
+		// an expanded acronym is restored as a whole
+		{"Convolutional Neural Networks (CNNs) are used. CNNs classify images.", []string{"CNNs " + stem("classify images")}, nil},
+	} {
+		got := restoredForms(test.text)
+		for _, want := range test.want {
+			if !got[want] {
+				t.Errorf("%q: %q not in %v", test.text, want, got)
+			}
+		}
+		for _, notWant := range test.notWant {
+			if got[notWant] {
+				t.Errorf("%q: %q in %v", test.text, notWant, got)
+			}
+		}
+	}
+}
+
+func TestExtractorZeroOptions(t *testing.T) {
+	// the zero options neither expand the acronyms nor drop the single chars
+	text := "Convolutional Neural Networks (CNNs) are used. CNNs classify images. Take vitamin B. B."
+	candidates, conversions := NewExtractor(Options{}).ExtractKeyPhraseCandidatesWithConversions(text)
+	if len(conversions) != 0 {
+		t.Errorf("conversions %v with the zero options", conversions)
+	}
+	hasB := false
+	for _, candidate := range candidates {
+		hasB = hasB || candidate == StemPhrases([]string{"b"})[0]
+	}
+	if !hasB {
+		t.Errorf("the single char is dropped from %q", candidates)
+	}
+
+	_, conversions = acronymExtractor.ExtractKeyPhraseCandidatesWithConversions(text)
+	numExpanded := 0
+	for _, conversion := range conversions {
+		if conversion.Kind == ConversionAcronymExpansion {
+			numExpanded++
+		}
+	}
+	if numExpanded != 1 {
+		t.Errorf("%d acronyms expanded in %v, want 1", numExpanded, conversions)
+	}
+}
@@ -0,0 +1,239 @@
+package KeyphraseExtraction
+
+import "strings"
+
+// EmbedRankOptions controls EmbedRank.
+type EmbedRankOptions struct {
+	// Extractor processes the text. nil uses the default options.
+	Extractor *Extractor
+
+	// SIFParameter is the a of the SIF weight a / (a + p(w)) of a word w. 0 means 1e-3.
+	SIFParameter float64
+
+	// WordProbabilities are the probabilities p(w) of the words, in lower case. nil estimates them
+	// from the ranks of the words in the vector file with Zipf's law.
+	WordProbabilities map[string]float64
+
+	// Diversify reorders the ranking with MMR, with Lambda as the balance between relevance and
+	// diversity.
+	Diversify bool
+	Lambda    float64
+
+	// K is the number of phrases returned by EmbedRank. 0 means all of them.
+	K int
+}
+
+// sifEmbedder embeds texts as the SIF-weighted averages of their word vectors.
+type sifEmbedder struct {
+	vectors       *WordVectors
+	parameter     float64
+	probabilities map[string]float64
+}
+
+// =================================================================================================
+// function newSIFEmbedder
+// brief description:
+//   Create an embedder of texts.
+// input:
+//   vectors: the word vectors
+//   parameter: the a of the SIF weights, or 0 for 1e-3
+//   probabilities: the probabilities of the words, or nil to estimate them with Zipf's law
+// output:
+//   the new embedder
+
+func newSIFEmbedder(vectors *WordVectors, parameter float64, probabilities map[string]float64) *sifEmbedder {
+	if parameter <= 0 {
+		parameter = 1e-3
+	}
+	return &sifEmbedder{vectors: vectors, parameter: parameter, probabilities: probabilities}
+}
+
+// =================================================================================================
+// method sifEmbedder.addWord
+// brief description:
+//   Add the weighted vector of a word to a sum, splitting the hyphened words that have no vectors.
+// input:
+//   word: the word, as it is normalized by the pipeline
+//   sum: the sum of weighted vectors
+// output:
+//   the weight added, which is 0 if the word has no vector
+
+func (embedder *sifEmbedder) addWord(word string, sum []float64) float64 {
+	parts := []string{word}
+	if _, exists := embedder.vectors.Rank(word); !exists && strings.Contains(word, "-") {
+		parts = strings.Split(word, "-")
+	}
+	result := 0.0
+	for _, part := range parts {
+		rank, exists := embedder.vectors.Rank(part)
+		if !exists {
+			continue
+		}
+		probability := 0.0
+		if embedder.probabilities != nil {
+			probability = embedder.probabilities[strings.ToLower(part)]
+		} else {
+			probability = embedder.vectors.zipfProbability(rank)
+		}
+		weight := embedder.parameter / (embedder.parameter + probability)
+		for i, value := range embedder.vectors.vectors[rank] {
+			sum[i] += weight * float64(value)
+		}
+		result += weight
+	}
+	return result
+}
+
+// =================================================================================================
+// method sifEmbedder.embed
+// brief description:
+//   Embed a sequence of tokens.
+// input:
+//   tokens: the tokens
+//   skipStopWords: whether the stop words are left out
+// output:
+//   the SIF-weighted average of the vectors of the tokens, or nil if none of them has a vector
+
+func (embedder *sifEmbedder) embed(tokens []Token, skipStopWords bool) []float64 {
+	sum := make([]float64, embedder.vectors.Dimension())
+	weight := 0.0
+	for _, tok := range tokens {
+		if skipStopWords && stopWords[strings.ToLower(tok.Normalized)] {
+			continue
+		}
+		weight += embedder.addWord(tok.Normalized, sum)
+	}
+	if weight == 0 {
+		return nil
+	}
+	for i := range sum {
+		sum[i] /= weight
+	}
+	return sum
+}
+
+// =================================================================================================
+// function embedCandidates
+// brief description:
+//   Embed the candidates of a document, averaging the embeddings of the occurrences of each stemmed
+//   phrase.
+// input:
+//   embedder: the embedder
+//   document: the processed document
+// output:
+//   the vector of each stemmed phrase that has at least one word with a vector
+
+func embedCandidates(embedder *sifEmbedder, document *Document) map[string][]float64 {
+	result := map[string][]float64{}
+	numOccurrences := map[string]float64{}
+	for _, candidate := range document.Candidates {
+		vector := embedder.embed(document.Tokens[candidate.Begin:candidate.End], false)
+		if vector == nil {
+			continue
+		}
+		sum, exists := result[candidate.Phrase]
+		if !exists {
+			result[candidate.Phrase] = vector
+		} else {
+			for i := range sum {
+				sum[i] += vector[i]
+			}
+		}
+		numOccurrences[candidate.Phrase] += 1.0
+	}
+	for phrase, sum := range result {
+		for i := range sum {
+			sum[i] /= numOccurrences[phrase]
+		}
+	}
+	return result
+}
+
+// =================================================================================================
+// function EmbedRankScores
+// brief description:
+//   Score the candidates of a document by the cosine similarity between their embeddings and the
+//   embedding of the document.
+// input:
+//   document: the processed document
+//   vectors: the word vectors
+//   options: the options of EmbedRank
+// output:
+//   the score of each stemmed phrase, leaving out the phrases none of whose words has a vector
+
+func EmbedRankScores(document *Document, vectors *WordVectors, options EmbedRankOptions) map[string]float64 {
+	result, _ := embedRankScores(document, vectors, options)
+	return result
+}
+
+// =================================================================================================
+// function embedRankScores
+// brief description:
+//   Score the candidates of a document as EmbedRankScores does, keeping their embeddings.
+// input:
+//   document: the processed document
+//   vectors: the word vectors
+//   options: the options of EmbedRank
+// output:
+//   the score and the embedding of each stemmed phrase
+
+func embedRankScores(document *Document, vectors *WordVectors,
+	options EmbedRankOptions) (map[string]float64, map[string][]float64) {
+	embedder := newSIFEmbedder(vectors, options.SIFParameter, options.WordProbabilities)
+	documentVector := embedder.embed(document.Tokens, true)
+	phraseVectors := embedCandidates(embedder, document)
+	result := make(map[string]float64, len(phraseVectors))
+	if documentVector == nil {
+		return result, phraseVectors
+	}
+	for phrase, vector := range phraseVectors {
+		result[phrase] = cosine(vector, documentVector)
+	}
+	return result, phraseVectors
+}
+
+// =================================================================================================
+// function EmbedRank
+// brief description:
+//   Rank the key phrase candidates of a text with word vectors, offline.
+// input:
+//   text: the input text
+//   vectors: the word vectors, e.g. from LoadWordVectors
+//   options: the options of EmbedRank
+// output:
+//   the stems of the key phrase candidates, from the most to the least similar to the text, or in
+//   the order of MMR if options.Diversify is set
+// notes:
+//   The reference is:
+//   Bennani-Smires, K., Musat, C., Hossmann, A., Baeriswyl, M., & Jaggi, M. (2018). Simple
+//   unsupervised keyphrase extraction using sentence embeddings. In CoNLL (pp. 221-229).
+//   The embeddings are the SIF-weighted averages of the word vectors, without the removal of the
+//   common component:
+//   Arora, S., Liang, Y., & Ma, T. (2017). A simple but tough-to-beat baseline for sentence
+//   embeddings. In ICLR.
+
+func EmbedRank(text string, vectors *WordVectors, options EmbedRankOptions) []string {
+	// --------------------------------------------------------------------------------------------
+	// step 1: process the text and score its candidates
+	extractor := options.Extractor
+	if extractor == nil {
+		extractor = defaultExtractor
+	}
+	scores, phraseVectors := embedRankScores(extractor.ExtractDocument(text), vectors, options)
+
+	// --------------------------------------------------------------------------------------------
+	// step 2: diversify the ranking with MMR, using the similarity between the embeddings
+	if options.Diversify {
+		return MMR(scores, options.K, options.Lambda, func(phrase1, phrase2 string) float64 {
+			return cosine(phraseVectors[phrase1], phraseVectors[phrase2])
+		})
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 3: otherwise sort the candidates by their scores
+	result := ArgSort(scores)
+	if options.K > 0 && options.K < len(result) {
+		result = result[:options.K]
+	}
+	return result
+}
@@ -0,0 +1,84 @@
+package KeyphraseExtraction
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestSIFEmbedder(t *testing.T) {
+	vectors := newWordVectors(2)
+	vectors.add("the", []float32{1, 0})
+	vectors.add("graph", []float32{0, 1})
+	vectors.add("model", []float32{1, 1})
+	tokens := []Token{{Normalized: "the"}, {Normalized: "Graph"}, {Normalized: "unknown"}}
+
+	// with given probabilities, the weight of a word w is a / (a + p(w))
+	probabilities := map[string]float64{"the": 0.05, "graph": 0.001}
+	embedder := newSIFEmbedder(vectors, 0.001, probabilities)
+	weightThe, weightGraph := 0.001/0.051, 0.001/0.002
+	sum := weightThe + weightGraph
+	expected := []float64{weightThe / sum, weightGraph / sum}
+	for i, value := range embedder.embed(tokens, false) {
+		if math.Abs(value-expected[i]) > 1e-12 {
+			t.Errorf("embedding %d is %v instead of %v", i, value, expected[i])
+		}
+	}
+
+	// the stop words are left out of the embedding of a document
+	if embedding := embedder.embed(tokens, true); embedding[0] != 0 || embedding[1] != 1 {
+		t.Errorf("embedding without stop words is %v instead of [0 1]", embedding)
+	}
+	if embedding := embedder.embed([]Token{{Normalized: "unknown"}}, false); embedding != nil {
+		t.Errorf("embedding of an unknown word is %v instead of nil", embedding)
+	}
+
+	// a hyphened word without a vector is split
+	addedWeight := embedder.addWord("graph-model", make([]float64, 2))
+	if math.Abs(addedWeight-(weightGraph+1)) > 1e-12 {
+		t.Errorf("weight of graph-model is %v instead of %v", addedWeight, weightGraph+1)
+	}
+
+	// without probabilities, the more frequent words, ranked first, weigh less
+	zipf := newSIFEmbedder(vectors, 0, nil)
+	if zipf.addWord("the", make([]float64, 2)) >= zipf.addWord("model", make([]float64, 2)) {
+		t.Error("the first word does not weigh less than the last one")
+	}
+}
+
+func TestEmbedRank(t *testing.T) {
+	vectors := newWordVectors(2)
+	vectors.add("graph", []float32{1, 0})
+	vectors.add("graphs", []float32{1, 0.2})
+	vectors.add("learning", []float32{1, 0.1})
+	vectors.add("networks", []float32{1, 0.3})
+	vectors.add("large", []float32{1, -0.5})
+	vectors.add("banana", []float32{0, 1})
+	vectors.add("bread", []float32{0.1, 1})
+	text := "Graph learning improves graph networks. Graph learning scales to large graphs. " +
+		"We also baked banana bread."
+	document := ExtractDocument(text)
+	scores := EmbedRankScores(document, vectors, EmbedRankOptions{})
+	banana := StemPhrases([]string{"banana"})[0]
+
+	ranking := EmbedRank(text, vectors, EmbedRankOptions{})
+	if len(ranking) != len(scores) {
+		t.Fatalf("ranking %v does not have the %d scored phrases", ranking, len(scores))
+	}
+	for i := 1; i < len(ranking); i++ {
+		if scores[ranking[i]] > scores[ranking[i-1]] {
+			t.Errorf("%q is ranked after %q with a higher score", ranking[i], ranking[i-1])
+		}
+	}
+	if !strings.Contains(ranking[len(ranking)-1], banana) {
+		t.Errorf("the last phrase of %v is not about bananas", ranking)
+	}
+
+	if top := EmbedRank(text, vectors, EmbedRankOptions{K: 2}); len(top) != 2 || top[0] != ranking[0] {
+		t.Errorf("top 2 phrases are %v instead of the first ones of %v", top, ranking)
+	}
+	diversified := EmbedRank(text, vectors, EmbedRankOptions{Diversify: true, Lambda: 0.3, K: 2})
+	if len(diversified) != 2 || !strings.Contains(diversified[1], banana) {
+		t.Errorf("diversified phrases %v do not cover bananas", diversified)
+	}
+}
@@ -0,0 +1,276 @@
+package KeyphraseExtraction
+
+import (
+	"math"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// EmbeddingSimilarityOptions controls the phrase similarity built from word vectors.
+type EmbeddingSimilarityOptions struct {
+	// Threshold is the lowest similarity kept between two different phrases. 0 means 0.5.
+	Threshold float64
+
+	// TopK is the number of nearest neighbors of each phrase, itself excluded, that are kept. A pair
+	// is kept if either phrase is among the nearest neighbors of the other, so that the matrix is
+	// symmetric, and a phrase may have more than TopK neighbors. 0 means no limit.
+	TopK int
+
+	// SIFParameter and WordProbabilities weight the word vectors as in EmbedRankOptions.
+	SIFParameter      float64
+	WordProbabilities map[string]float64
+}
+
+// =================================================================================================
+// function alignStems
+// brief description:
+//   Align the stemmed words of a candidate with the surface words of its tokens.
+// input:
+//   document: the processed document
+//   candidate: a candidate of the document
+// output:
+//   the surface word of each stemmed word of the candidate, or nil if they cannot be aligned, e.g.
+//   for the joined variant of a hyphened word
+
+func alignStems(document *Document, candidate Candidate) []string {
+	result := []string{}
+	for _, tok := range document.Tokens[candidate.Begin:candidate.End] {
+		stems := strings.Split(tok.Stem, " ")
+		if len(stems) == 1 {
+			result = append(result, tok.Normalized)
+			continue
+		}
+		// a hyphened word split into its parts
+		parts := strings.FieldsFunc(tok.Normalized, func(r rune) bool { return !isWordRune(r) })
+		if len(parts) != len(stems) {
+			return nil
+		}
+		result = append(result, parts...)
+	}
+	if len(result) != strings.Count(candidate.Phrase, " ")+1 {
+		return nil
+	}
+	return result
+}
+
+// =================================================================================================
+// function PhraseVectors
+// brief description:
+//   Embed the stemmed phrases of some documents, and all their n-grams, with word vectors.
+// input:
+//   documents: the processed documents
+//   vectors: the word vectors
+//   options: the weights of the word vectors
+// output:
+//   the vector of each stemmed phrase or n-gram, averaged over its occurrences, for the phrases
+//   that have at least one word with a vector
+// notes:
+//   The stems are aligned with the surface words of the tokens they come from, so that "network"
+//   is embedded with the vectors of "networks" and "network" as they occur in the documents.
+
+func PhraseVectors(documents []*Document, vectors *WordVectors, options EmbeddingSimilarityOptions) map[string][]float64 {
+	// --------------------------------------------------------------------------------------------
+	// step 1: sum the vectors of the occurrences of the n-grams
+	embedder := newSIFEmbedder(vectors, options.SIFParameter, options.WordProbabilities)
+	sums := map[string][]float64{}
+	numOccurrences := map[string]float64{}
+	accumulate := func(phrase string, surfaces []string) {
+		sum := make([]float64, vectors.Dimension())
+		weight := 0.0
+		for _, surface := range surfaces {
+			weight += embedder.addWord(surface, sum)
+		}
+		if weight == 0 {
+			return
+		}
+		total, exists := sums[phrase]
+		if !exists {
+			total = make([]float64, vectors.Dimension())
+			sums[phrase] = total
+		}
+		for i := range total {
+			total[i] += sum[i] / weight
+		}
+		numOccurrences[phrase] += 1.0
+	}
+	for _, document := range documents {
+		for _, candidate := range document.Candidates {
+			surfaces := alignStems(document, candidate)
+			if surfaces == nil {
+				// the phrase as a whole, with the surface words of all its tokens
+				for _, tok := range document.Tokens[candidate.Begin:candidate.End] {
+					surfaces = append(surfaces, tok.Normalized)
+				}
+				accumulate(candidate.Phrase, surfaces)
+				continue
+			}
+			stems := strings.Split(candidate.Phrase, " ")
+			numWords := len(stems)
+			for i := 0; i < numWords; i++ {
+				for j := i + 1; j <= numWords; j++ {
+					accumulate(strings.Join(stems[i:j], " "), surfaces[i:j])
+				}
+			}
+		}
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 2: average the vectors
+	for phrase, sum := range sums {
+		for i := range sum {
+			sum[i] /= numOccurrences[phrase]
+		}
+	}
+	return sums
+}
+
+// phraseNeighbor is a phrase, by its index, and its similarity to another phrase.
+type phraseNeighbor struct {
+	index      int
+	similarity float64
+}
+
+// =================================================================================================
+// function similarityRow
+// brief description:
+//   Build a row of a sparse similarity matrix from the neighbors of a phrase.
+// input:
+//   phrases: the phrases, by their indices
+//   index: the index of the phrase of the row
+//   neighbors: the neighbors of the phrase, itself excluded, which may be reordered
+//   topK: the largest number of neighbors kept, or 0 for no limit
+// output:
+//   the row, with a similarity of 1 of the phrase to itself
+
+func similarityRow(phrases []string, index int, neighbors []phraseNeighbor, topK int) map[string]float64 {
+	if topK > 0 && len(neighbors) > topK {
+		sort.Slice(neighbors, func(a, b int) bool {
+			return neighbors[a].similarity > neighbors[b].similarity
+		})
+		neighbors = neighbors[:topK]
+	}
+	result := make(map[string]float64, len(neighbors)+1)
+	result[phrases[index]] = 1.0
+	for _, neighbor := range neighbors {
+		result[phrases[neighbor.index]] = neighbor.similarity
+	}
+	return result
+}
+
+// =================================================================================================
+// function symmetrizeRows
+// brief description:
+//   Make a sparse similarity matrix symmetric, after the neighbors of each row have been pruned.
+// input:
+//   similarity: the rows of the matrix, one for each phrase, completed in place so that a pair is
+//               kept if it is in the row of either phrase
+
+func symmetrizeRows(similarity map[string]map[string]float64) {
+	for phrase1, row := range similarity {
+		for phrase2, sim := range row {
+			similarity[phrase2][phrase1] = sim
+		}
+	}
+}
+
+// =================================================================================================
+// function similarityFromVectors
+// brief description:
+//   Build a sparse similarity matrix from phrase vectors, comparing all pairs.
+// input:
+//   phraseVectors: the vector of each phrase
+//   threshold: the lowest similarity kept between two different phrases
+//   topK: the number of nearest neighbors of each phrase kept, or 0 for no limit
+// output:
+//   the symmetric cosine similarity between the phrases, with a similarity of 1 of each phrase to
+//   itself
+
+func similarityFromVectors(phraseVectors map[string][]float64, threshold float64,
+	topK int) map[string]map[string]float64 {
+	// --------------------------------------------------------------------------------------------
+	// step 1: normalize the vectors
+	phrases := make([]string, 0, len(phraseVectors))
+	normalized := make([][]float64, 0, len(phraseVectors))
+	for phrase, vector := range phraseVectors {
+		norm := 0.0
+		for _, value := range vector {
+			norm += value * value
+		}
+		if norm == 0 {
+			continue
+		}
+		norm = math.Sqrt(norm)
+		unit := make([]float64, len(vector))
+		for i, value := range vector {
+			unit[i] = value / norm
+		}
+		phrases = append(phrases, phrase)
+		normalized = append(normalized, unit)
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 2: find the neighbors of each phrase on all the CPUs
+	numPhrases := len(phrases)
+	rows := make([]map[string]float64, numPhrases)
+	chI := make(chan int)
+	var wg sync.WaitGroup
+	for idxCPU := 0; idxCPU < runtime.NumCPU(); idxCPU++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range chI {
+				neighbors := []phraseNeighbor{}
+				for j := 0; j < numPhrases; j++ {
+					if j == i {
+						continue
+					}
+					sim := 0.0
+					for d, value := range normalized[i] {
+						sim += value * normalized[j][d]
+					}
+					if sim >= threshold {
+						neighbors = append(neighbors, phraseNeighbor{j, sim})
+					}
+				}
+				rows[i] = similarityRow(phrases, i, neighbors, topK)
+			}
+		}()
+	}
+	for i := 0; i < numPhrases; i++ {
+		chI <- i
+	}
+	close(chI)
+	wg.Wait()
+
+	// --------------------------------------------------------------------------------------------
+	// step 3: return the result
+	result := make(map[string]map[string]float64, numPhrases)
+	for i, row := range rows {
+		result[phrases[i]] = row
+	}
+	symmetrizeRows(result)
+	return result
+}
+
+// =================================================================================================
+// function EmbeddingSimilarity
+// brief description:
+//   Build the phraseSimilarity of SimTF and SimIDF from word vectors.
+// input:
+//   documents: the processed documents, whose candidates and their n-grams are compared
+//   vectors: the word vectors
+//   options: the options of the similarity
+// output:
+//   a sparse matrix that gives the cosine similarity between the stemmed phrases, with a
+//   similarity of 1 of each phrase to itself
+
+func EmbeddingSimilarity(documents []*Document, vectors *WordVectors,
+	options EmbeddingSimilarityOptions) map[string]map[string]float64 {
+	threshold := options.Threshold
+	if threshold == 0 {
+		threshold = 0.5
+	}
+	return similarityFromVectors(PhraseVectors(documents, vectors, options), threshold, options.TopK)
+}
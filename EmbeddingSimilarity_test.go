@@ -0,0 +1,27 @@
+package KeyphraseExtraction
+
+import "testing"
+
+func TestSimilarityFromVectorsSymmetric(t *testing.T) {
+	// the nearest neighbor of "a" is "b", but the nearest neighbor of "b" is "c"
+	vectors := map[string][]float64{
+		"a": {1, 0, 0},
+		"b": {0.8, 0.6, 0},
+		"c": {0.6, 0.8, 0},
+		"d": {0, 0, 1},
+	}
+	similarity := similarityFromVectors(vectors, 0.1, 1)
+	for phrase1, row := range similarity {
+		for phrase2, sim := range row {
+			if similarity[phrase2][phrase1] != sim {
+				t.Errorf("similarity of %q to %q is %v, back %v", phrase1, phrase2, sim, similarity[phrase2][phrase1])
+			}
+		}
+	}
+	if _, exists := similarity["b"]["a"]; !exists {
+		t.Error("the pair a, b is lost")
+	}
+	if len(similarity["d"]) != 1 {
+		t.Errorf("d has neighbors %v below the threshold", similarity["d"])
+	}
+}
@@ -0,0 +1,234 @@
+package KeyphraseExtraction
+
+// Options controls the stages of the pipeline that extracts key phrase candidates.
+type Options struct {
+	// HyphenMode tells how hyphened words are handled.
+	HyphenMode HyphenMode
+
+	// DropNumericCandidates drops the candidates made of numbers only, e.g. "2" or "1000".
+	DropNumericCandidates bool
+
+	// FoldDiacritics removes the diacritics from letters, e.g. "naïve" becomes "naive".
+	FoldDiacritics bool
+
+	// ExpandAcronyms normalizes the plural acronyms, e.g. "CNNs" to "CNN", and replaces the mentions
+	// of the acronyms defined in the text, e.g. "SVM" after "Support Vector Machine (SVM)", with
+	// their long forms, so that an acronym and its long form are counted as a single candidate.
+	ExpandAcronyms bool
+
+	// KeepGreekLetters keeps Greek letters as they are instead of spelling them out, e.g. "α"
+	// stays "α" instead of becoming "alpha".
+	KeepGreekLetters bool
+
+	// Filter holds the rules that drop poor candidates, e.g. DefaultCandidateFilter. The zero
+	// value keeps all the candidates.
+	Filter CandidateFilter
+
+	// TagPOS tags the tokens of a Document with their parts of speech, which is slower.
+	TagPOS bool
+
+	// StemCache caches the stems of words. nil uses the cache shared with StemPhrases.
+	StemCache *StemCache
+}
+
+// Extractor extracts key phrase candidates with a set of options.
+type Extractor struct {
+	options Options
+}
+
+var defaultExtractor = NewExtractor(DefaultOptions())
+
+// =================================================================================================
+// function DefaultOptions
+// brief description:
+//   Get the default options of the pipeline, which are the zero options.
+// output:
+//   The options used by ExtractKeyPhraseCandidates, which split the hyphened words and neither
+//   expand the acronyms nor filter the candidates.
+
+func DefaultOptions() Options {
+	return Options{
+		HyphenMode: HyphenSplit,
+	}
+}
+
+// =================================================================================================
+// function NewExtractor
+// brief description:
+//   Create an extractor with a set of options.
+// input:
+//   options: The options of the pipeline.
+// output:
+//   The new extractor.
+
+func NewExtractor(options Options) *Extractor {
+	if options.StemCache == nil {
+		options.StemCache = defaultStemCache
+	}
+	return &Extractor{options: options}
+}
+
+// =================================================================================================
+// method Extractor.Options
+// brief description:
+//   Get the options of the extractor.
+// output:
+//   The options of the extractor.
+
+func (extractor *Extractor) Options() Options {
+	return extractor.options
+}
+
+// =================================================================================================
+// method Extractor.ExtractKeyPhraseCandidates
+// brief description:
+//   Search from the input text for key phrase candidates.
+// input:
+//   text: The input text.
+// output:
+//   A vector of the stems of the key phrase candidates.
+
+func (extractor *Extractor) ExtractKeyPhraseCandidates(text string) []string {
+	result, _ := extractor.ExtractKeyPhraseCandidatesWithConversions(text)
+	return result
+}
+
+// =================================================================================================
+// method Extractor.ExtractKeyPhraseCandidatesWithConversions
+// brief description:
+//   Search from the input text for key phrase candidates, and report the conversions made on the
+//   way.
+// input:
+//   text: The input text.
+// output:
+//   A vector of the stems of the key phrase candidates, and the conversions, located in the text by
+//   their byte ranges. See Document.RestoreSurfaceForm to restore the candidates.
+
+func (extractor *Extractor) ExtractKeyPhraseCandidatesWithConversions(text string) ([]string, []Conversion) {
+	document := extractor.ExtractDocument(text)
+	return document.CandidatePhrases(), document.Conversions
+}
+
+// =================================================================================================
+// method Extractor.ExtractDocument
+// brief description:
+//   Process the input text, keeping its sentences, tokens and candidates.
+// input:
+//   text: The input text.
+// output:
+//   The processed document.
+
+func (extractor *Extractor) ExtractDocument(text string) *Document {
+	options := extractor.options
+
+	// --------------------------------------------------------------------------------------------
+	// step 1: Normalize the Unicode text, then tokenize it into words and puntuations.
+	normalizedText, offsets := normalizeText(text, options)
+	tokens := tokenizeText(normalizedText, options.TagPOS)
+
+	// --------------------------------------------------------------------------------------------
+	// step 2: Replace the acronyms with their definitions if asked, convert roman numbers to arabic
+	//         numbers, then normalize the numbers and units
+	conversions := []Conversion{}
+	if options.ExpandAcronyms {
+		tokens, conversions = expandAcronyms(tokens, findAcronymDefinitions(tokenTexts(tokens)))
+	}
+	romanTexts, romanConversions := convertRomanNumerals(tokens)
+	for i := range tokens {
+		tokens[i].text = romanTexts[i]
+	}
+	conversions = append(conversions, romanConversions...)
+	tokens, numberConversions := normalizeNumbers(tokens)
+	conversions = append(conversions, numberConversions...)
+	for i := range conversions {
+		conversions[i].Begin, conversions[i].End = offsets[conversions[i].Begin], offsets[conversions[i].End]
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 3: Build the sentences and the tokens of the document, converting non-abbreviation words
+	//         to lower case, and group the words into phrases separated by puntuations
+	document := &Document{Text: text, Conversions: conversions}
+	groups := []tokenSpan{}
+	groupBegin := 0
+	sentenceBegin := 0
+	sentenceOffset := -1
+	sentenceEnd := 0
+	closeSentence := func() {
+		numTokens := len(document.Tokens)
+		if numTokens > groupBegin {
+			groups = append(groups, tokenSpan{begin: groupBegin, end: numTokens})
+		}
+		groupBegin = numTokens
+		if numTokens > sentenceBegin {
+			document.Sentences = append(document.Sentences, Sentence{
+				Text:   text[sentenceOffset:sentenceEnd],
+				Offset: sentenceOffset,
+				Begin:  sentenceBegin,
+				End:    numTokens,
+			})
+		}
+		sentenceBegin = numTokens
+		sentenceOffset = -1
+	}
+	for _, tok := range tokens {
+		begin, end := offsets[tok.begin], offsets[tok.end]
+		if punctuations[tok.text] {
+			numTokens := len(document.Tokens)
+			if numTokens > groupBegin {
+				groups = append(groups, tokenSpan{begin: groupBegin, end: numTokens})
+			}
+			groupBegin = numTokens
+			if sentenceOffset >= 0 {
+				sentenceEnd = end
+			}
+			if sentenceEnders[tok.text] {
+				closeSentence()
+			}
+			continue
+		}
+		if sentenceOffset < 0 {
+			sentenceOffset = begin
+		}
+		sentenceEnd = end
+		normalized := convertNonAbbreviationToLowercase(tok.text)
+		document.Tokens = append(document.Tokens, Token{
+			Text:       text[begin:end],
+			Offset:     begin,
+			Normalized: normalized,
+			Stem:       options.StemCache.stemPhrases(separateHyphenedWords([][]string{{normalized}}, options.HyphenMode))[0],
+			POS:        tok.pos,
+			Sentence:   len(document.Sentences),
+		})
+	}
+	closeSentence()
+
+	// --------------------------------------------------------------------------------------------
+	// step 4: Use stop words and blacklisted words to separate words into candidate phrases
+	words := make([]string, len(document.Tokens))
+	for i, tok := range document.Tokens {
+		words[i] = tok.Normalized
+	}
+	spans := separateSpansWithStopWords(words, groups)
+	spans = options.Filter.separate(words, spans)
+
+	// --------------------------------------------------------------------------------------------
+	// step 5: Handle the hyphened words, drop the poor candidates, then stem the others
+	for _, span := range spans {
+		spanWords := words[span.begin:span.end]
+		if options.DropNumericCandidates && isNumericPhrase(spanWords) {
+			continue
+		}
+		for _, variant := range separateHyphenedWords([][]string{spanWords}, options.HyphenMode) {
+			if !options.Filter.accepts(variant) {
+				continue
+			}
+			document.Candidates = append(document.Candidates, Candidate{
+				Phrase:   options.StemCache.stemPhrases([][]string{variant})[0],
+				Sentence: document.Tokens[span.begin].Sentence,
+				Begin:    span.begin,
+				End:      span.end,
+			})
+		}
+	}
+	return document
+}
@@ -0,0 +1,72 @@
+package KeyphraseExtraction
+
+import (
+	"unicode"
+)
+
+// HyphenMode tells how hyphened words such as "state-of-the-art" and "e-mail" are handled.
+type HyphenMode int
+
+const (
+	// HyphenSplit separates a hyphened word into its parts, e.g. "e-mail" to "e mail".
+	HyphenSplit HyphenMode = iota
+	// HyphenJoin joins the parts of a hyphened word into one word, e.g. "e-mail" to "email".
+	HyphenJoin
+	// HyphenKeep keeps a hyphened word as it is.
+	HyphenKeep
+	// HyphenBoth emits both the separated and the joined variants of a phrase.
+	HyphenBoth
+)
+
+// =================================================================================================
+// function isWordRune
+// brief description:
+//   Check whether a rune is a letter or a digit.
+// input:
+//   r: The input rune.
+// output:
+//   true if r is a letter or a digit, false otherwise.
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// =================================================================================================
+// function normalizeDashes
+// brief description:
+//   Normalize the Unicode hyphens and dashes in the input text, so that hyphened words are written
+//   with ASCII hyphens and dashes between clauses become separate puntuations.
+// input:
+//   runes: The runes of the input text.
+// output:
+//   The runes with:
+//   (1) soft hyphens removed,
+//   (2) hyphens (U+2010, U+2011, U+2012, U+2212, U+FE63, U+FF0D) replaced with "-",
+//   (3) en dashes between two letters or digits, e.g. "Smith–Waterman", replaced with "-",
+//   (4) other en dashes and em dashes surrounded by spaces.
+
+func normalizeDashes(runes []offsetRune) []offsetRune {
+	numRunes := len(runes)
+	result := make([]offsetRune, 0, numRunes)
+	for i, or := range runes {
+		switch or.r {
+		case '\u00ad':
+			// soft hyphen
+		case '‐', '‑', '‒', '−', '﹣', '－':
+			result = append(result, offsetRune{r: '-', offset: or.offset})
+		case '–':
+			if i > 0 && i+1 < numRunes && isWordRune(runes[i-1].r) && isWordRune(runes[i+1].r) {
+				result = append(result, offsetRune{r: '-', offset: or.offset})
+			} else {
+				result = append(result, offsetRune{r: ' ', offset: or.offset}, or,
+					offsetRune{r: ' ', offset: or.offset})
+			}
+		case '—', '―':
+			result = append(result, offsetRune{r: ' ', offset: or.offset}, or,
+				offsetRune{r: ' ', offset: or.offset})
+		default:
+			result = append(result, or)
+		}
+	}
+	return result
+}
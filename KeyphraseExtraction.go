@@ -7,12 +7,13 @@ import (
 	"runtime"
 	"sort"
 	"strings"
+	"unicode"
 
 	"github.com/jdkato/prose"
-	"github.com/kljensen/snowball/english"
 )
 
 var punctuations map[string]bool
+var sentenceEnders map[string]bool
 var stopWords map[string]bool
 var reNumber *regexp.Regexp
 var reRomanNumber *regexp.Regexp
@@ -20,6 +21,31 @@ var reHyphenedWords *regexp.Regexp
 var romanHundredParts [9]string
 var romanTenParts [9]string
 var romanOneParts [9]string
+var romanCues [][]string
+var romanExceptions map[string]bool
+var romanListMarkerClosers map[string]bool
+
+// ConversionKind tells which stage of the pipeline has rewritten a token.
+type ConversionKind int
+
+const (
+	ConversionRomanNumeral ConversionKind = iota
+	ConversionAcronymPlural
+	ConversionAcronymExpansion
+	ConversionNumber
+)
+
+// Conversion records a token that was rewritten by the pipeline, so that the rewriting can be undone
+// when surface forms are displayed.
+type Conversion struct {
+	Kind      ConversionKind
+	Original  string
+	Converted string
+
+	// Begin and End are the byte range of the text that the rewritten tokens come from.
+	Begin int
+	End   int
+}
 
 func init() {
 	punctuations = make(map[string]bool)
@@ -51,6 +77,17 @@ func init() {
 	punctuations["”"] = true
 	punctuations["`"] = true
 	punctuations["…"] = true
+	punctuations["–"] = true
+	punctuations["—"] = true
+	punctuations["―"] = true
+
+	sentenceEnders = make(map[string]bool)
+	sentenceEnders["."] = true
+	sentenceEnders["。"] = true
+	sentenceEnders["!"] = true
+	sentenceEnders["！"] = true
+	sentenceEnders["?"] = true
+	sentenceEnders["？"] = true
 
 	stopWords = make(map[string]bool)
 	stopWords["a"] = true
@@ -90,7 +127,7 @@ func init() {
 
 	reNumber = regexp.MustCompile("^[0-9]+$")
 	reRomanNumber = regexp.MustCompile("^[iIvVxXlLcCdDmM]+$")
-	reHyphenedWords = regexp.MustCompile("^[a-zA-Z][a-zA-Z0-9]*(-[a-zA-Z][a-zA-Z0-9]*)+$")
+	reHyphenedWords = regexp.MustCompile(`^\pL[\pL\pN]*(-\pL[\pL\pN]*)+$`)
 
 	// roman hundred parts: "cm","dccc","dcc","dc","d","cd","ccc","cc","c"
 	romanHundredParts[0] = "cm"
@@ -124,53 +161,125 @@ func init() {
 	romanOneParts[6] = "iii"
 	romanOneParts[7] = "ii"
 	romanOneParts[8] = "i"
+
+	// cue words after which an uppercase roman number is taken as a number, e.g. "Part IV",
+	// "World War II", "Type I"
+	romanCues = [][]string{
+		{"part"}, {"chapter"}, {"section"}, {"volume"}, {"book"}, {"appendix"}, {"article"},
+		{"type"}, {"phase"}, {"stage"}, {"step"}, {"class"}, {"grade"}, {"level"}, {"tier"},
+		{"act"}, {"table"}, {"figure"}, {"world", "war"},
+	}
+
+	// real words and acronyms that happen to be valid roman numbers
+	romanExceptions = make(map[string]bool)
+	romanExceptions["cc"] = true
+	romanExceptions["cd"] = true
+	romanExceptions["cdi"] = true
+	romanExceptions["ci"] = true
+	romanExceptions["civ"] = true
+	romanExceptions["cli"] = true
+	romanExceptions["cm"] = true
+	romanExceptions["cv"] = true
+	romanExceptions["dc"] = true
+	romanExceptions["di"] = true
+	romanExceptions["dix"] = true
+	romanExceptions["dl"] = true
+	romanExceptions["li"] = true
+	romanExceptions["lv"] = true
+	romanExceptions["lx"] = true
+	romanExceptions["mc"] = true
+	romanExceptions["mci"] = true
+	romanExceptions["md"] = true
+	romanExceptions["mdc"] = true
+	romanExceptions["mdi"] = true
+	romanExceptions["mi"] = true
+	romanExceptions["mil"] = true
+	romanExceptions["mix"] = true
+	romanExceptions["ml"] = true
+	romanExceptions["mm"] = true
+	romanExceptions["mmc"] = true
+	romanExceptions["vi"] = true
+	romanExceptions["xl"] = true
+
+	// tokens that close a list marker such as "(iv)" or "II."
+	romanListMarkerClosers = make(map[string]bool)
+	romanListMarkerClosers[")"] = true
+	romanListMarkerClosers["）"] = true
+	romanListMarkerClosers["."] = true
+}
+
+// token is a word or a puntuation of the text being processed, with the byte range [begin, end) it
+// comes from.
+type token struct {
+	text  string
+	pos   string
+	begin int
+	end   int
+}
+
+// tokenSpan is the range [begin, end) of a sequence of tokens.
+type tokenSpan struct {
+	begin int
+	end   int
 }
 
 // =================================================================================================
-// function tokenizeIntoWords
+// function tokenizeText
 // brief description:
-//   Tokenize the input text into words and puntuations, then remove the puntuations.
+//   Tokenize the input text into words and puntuations.
 // input:
 //   text: The input text.
+//   tagPOS: Whether to tag the tokens with their parts of speech.
 // output:
-//   The tokens of the text grouped by phrases separated by puntuations.
-
-func tokenizeIntoWords(text string) [][]string {
-	// --------------------------------------------------------------------------------------------
-	// step 1: Tokenize the input text into words and puntuations.
-	tokenizer := prose.NewIterTokenizer()
-	toks := tokenizer.Tokenize(text)
-
-	// --------------------------------------------------------------------------------------------
-	// step 2: Remove the puntuations and group the words into phrases separated by the puntuations.
-	result := [][]string{make([]string, 0)}
-	for _, tok := range toks {
-		_, isPunctuation := punctuations[tok.Text]
-		numPhrases := len(result)
-		numWordsInLastPhrase := len(result[numPhrases-1])
-		if !isPunctuation {
-			if numWordsInLastPhrase > 0 {
-				// recorrect some incorrect tokenization
-				prevWord := result[numPhrases-1][numWordsInLastPhrase-1]
-				if tok.Text == "D" || tok.Text == "d" && reNumber.MatchString(prevWord) {
-					result[numPhrases-1][numWordsInLastPhrase-1] = prevWord + "d"
-				} else {
-					result[numPhrases-1] = append(result[numPhrases-1], tok.Text)
-				}
-			} else {
-				result[numPhrases-1] = append(result[numPhrases-1], tok.Text)
-			}
-		} else if numWordsInLastPhrase > 0 {
-			result = append(result, make([]string, 0))
+//   The tokens of the text, puntuations included.
+
+func tokenizeText(text string, tagPOS bool) []token {
+	// --------------------------------------------------------------------------------------------
+	// step 1: Tokenize the input text, with the tagger if the parts of speech are wanted
+	toks := []prose.Token{}
+	if tagPOS {
+		doc, err := prose.NewDocument(text, prose.WithExtraction(false), prose.WithSegmentation(false))
+		if err == nil {
+			toks = doc.Tokens()
+		} else {
+			tagPOS = false
+		}
+	}
+	if !tagPOS {
+		for _, tok := range prose.NewIterTokenizer().Tokenize(text) {
+			toks = append(toks, *tok)
 		}
 	}
 
 	// --------------------------------------------------------------------------------------------
-	// step 2: Return the result.
-	numPhrases := len(result)
-	numWordsInLastPhrase := len(result[numPhrases-1])
-	if numWordsInLastPhrase == 0 {
-		result = result[:numPhrases-1]
+	// step 2: Locate the tokens in the text
+	result := make([]token, len(toks))
+	cursor := 0
+	for i, tok := range toks {
+		result[i] = token{text: tok.Text, pos: tok.Tag, begin: cursor, end: cursor}
+		idx := strings.Index(text[cursor:], tok.Text)
+		if idx >= 0 {
+			result[i].begin = cursor + idx
+			result[i].end = cursor + idx + len(tok.Text)
+			cursor = result[i].end
+		}
+	}
+	return result
+}
+
+// =================================================================================================
+// function tokenTexts
+// brief description:
+//   Get the texts of a sequence of tokens.
+// input:
+//   tokens: The tokens.
+// output:
+//   The texts of the tokens.
+
+func tokenTexts(tokens []token) []string {
+	result := make([]string, len(tokens))
+	for i, tok := range tokens {
+		result[i] = tok.text
 	}
 	return result
 }
@@ -267,6 +376,116 @@ func convertRomanToArabic(text string) string {
 	}
 }
 
+// =================================================================================================
+// function followsRomanCue
+// brief description:
+//   Check whether a token follows one of the cue words of roman numbers, e.g. "Part" or "World War".
+// input:
+//   tokens: The tokens of a text, puntuations included.
+//   idx: The index of the token to check.
+// output:
+//   true if tokens[idx] follows a cue word, false otherwise.
+
+func followsRomanCue(tokens []string, idx int) bool {
+	for _, cue := range romanCues {
+		numCueWords := len(cue)
+		if idx < numCueWords {
+			continue
+		}
+		matched := true
+		for j, cueWord := range cue {
+			if strings.ToLower(tokens[idx-numCueWords+j]) != cueWord {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// =================================================================================================
+// function isRomanListMarker
+// brief description:
+//   Check whether a token is a list marker such as "(ii)" or "IV." at the beginning of a phrase.
+// input:
+//   tokens: The tokens of a text, puntuations included.
+//   idx: The index of the token to check.
+// output:
+//   true if tokens[idx] is a list marker, false otherwise.
+
+func isRomanListMarker(tokens []string, idx int) bool {
+	// --------------------------------------------------------------------------------------------
+	// step 1: A list marker starts the text or follows a puntuation, and is closed by ")" or ".".
+	if idx > 0 && !punctuations[tokens[idx-1]] {
+		return false
+	}
+	if idx+1 >= len(tokens) || !romanListMarkerClosers[tokens[idx+1]] {
+		return false
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 2: It must be written in a single case, and must not be a letter of an alphabetic list
+	//         such as "(c)" or "D."
+	word := tokens[idx]
+	lowercaseWord := strings.ToLower(word)
+	if word != lowercaseWord && word != strings.ToUpper(word) {
+		return false
+	}
+	switch lowercaseWord {
+	case "c", "d", "l", "m":
+		return false
+	}
+	return true
+}
+
+// =================================================================================================
+// function convertRomanNumerals
+// brief description:
+//   Convert the roman numbers in a sequence of tokens to arabic numbers, using the context of each
+//   token to tell roman numbers from words that merely look like them.
+// input:
+//   tokens: The tokens of a text, puntuations included.
+// output:
+//   The converted texts of the tokens, and the conversions that have been made.
+// notes:
+//   A token is taken as a roman number if it is
+//   (1) a list marker such as "(iv)" or "II.",
+//   (2) written in uppercase and following a cue word such as "Part", "Type" or "World War", or
+//   (3) written in uppercase with more than one letter and not in the exception list of real words
+//       and acronyms, e.g. "XIV" but not "CD" or "MD".
+
+func convertRomanNumerals(toks []token) ([]string, []Conversion) {
+	tokens := tokenTexts(toks)
+	result := make([]string, len(tokens))
+	conversions := []Conversion{}
+	for idx, token := range tokens {
+		result[idx] = token
+		arabic := convertRomanToArabic(token)
+		if arabic == token {
+			continue
+		}
+
+		isUppercase := token == strings.ToUpper(token)
+		isRomanNumber := isRomanListMarker(tokens, idx) ||
+			isUppercase && followsRomanCue(tokens, idx) ||
+			isUppercase && len(token) > 1 && !romanExceptions[strings.ToLower(token)]
+		if isRomanNumber {
+			result[idx] = arabic
+			conversions = append(conversions, Conversion{
+				Kind:      ConversionRomanNumeral,
+				Original:  token,
+				Converted: arabic,
+				Begin:     toks[idx].begin,
+				End:       toks[idx].end,
+			})
+		}
+	}
+	return result, conversions
+}
+
 // =================================================================================================
 // function convertNonAbbreviationToLowercase
 // brief description :
@@ -278,17 +497,31 @@ func convertRomanToArabic(text string) string {
 //   (1) the original text if the original text has all its letters written in uppercase (with the
 //       allowed exception of the last letter being 's'),
 //   (2) the lowercase version of the original text in other cases.
+// notes:
+//   The text is checked rune by rune, so that a single non-ASCII letter such as "É" is not taken as
+//   an abbreviation, and a text without any uppercase letter such as "日本" is not either.
 
 func convertNonAbbreviationToLowercase(text string) string {
 	// --------------------------------------------------------------------------------------------
-	// Do not convert it if all its chars are capital (except that the last char is allowed to be a
-	// lowercase 's')
-	if len(text) > 1 {
-		if text == strings.ToUpper(text) {
-			return text
+	// Do not convert it if all its letters are capital (except that the last letter is allowed to
+	// be a lowercase 's')
+	runes := []rune(text)
+	numRunes := len(runes)
+	if numRunes > 1 {
+		// a plural abbreviation such as "CNNs", but not a capitalized word such as "As"
+		if runes[numRunes-1] == 's' && numRunes > 2 {
+			runes = runes[:numRunes-1]
 		}
-		lenText := len(text)
-		if text[lenText-1] == 's' && text[:lenText-1] == strings.ToUpper(text[:lenText-1]) {
+		hasUppercase := false
+		hasLowercase := false
+		for _, r := range runes {
+			if unicode.IsUpper(r) {
+				hasUppercase = true
+			} else if unicode.IsLower(r) {
+				hasLowercase = true
+			}
+		}
+		if hasUppercase && !hasLowercase {
 			return text
 		}
 	}
@@ -299,44 +532,30 @@ func convertNonAbbreviationToLowercase(text string) string {
 }
 
 // =================================================================================================
-// function separateTextWithStopWords
+// function separateSpansWithStopWords
 // brief description:
-//   Use stop words to seperate a sequence of words into candidate phrases.
+//   Use stop words to seperate sequences of words into candidate phrases.
 // input:
-//   phrases: a vector of word groups separated by puntuations.
+//   words: The words of a text.
+//   spans: The spans of the word groups separated by puntuations.
 // output:
-//   a vector of candidate phrases
-
-func separateTextWithStopWords(phrases [][]string) [][]string {
-	// --------------------------------------------------------------------------------------------
-	// step 1: Prepare the result
-	result := [][]string{make([]string, 0)}
-
-	// --------------------------------------------------------------------------------------------
-	// step 2: Convert the input word sequence into a sequence of candidate phrases
-	for _, phrase := range phrases {
-		// Must keep the phrases separated by puntuations
-		if len(result[len(result)-1]) > 0 {
-			result = append(result, make([]string, 0))
-		}
-		// Separate the phrases further using stop words
-		for _, word := range phrase {
-			_, isStopWord := stopWords[word]
-			if isStopWord {
-				if len(result[len(result)-1]) > 0 {
-					result = append(result, make([]string, 0))
+//   The spans of the candidate phrases.
+
+func separateSpansWithStopWords(words []string, spans []tokenSpan) []tokenSpan {
+	result := []tokenSpan{}
+	for _, span := range spans {
+		begin := span.begin
+		for idxWord := span.begin; idxWord < span.end; idxWord++ {
+			if stopWords[words[idxWord]] {
+				if idxWord > begin {
+					result = append(result, tokenSpan{begin: begin, end: idxWord})
 				}
-			} else {
-				result[len(result)-1] = append(result[len(result)-1], word)
+				begin = idxWord + 1
 			}
 		}
-	}
-
-	// --------------------------------------------------------------------------------------------
-	// step 3: Return the result
-	if len(result[len(result)-1]) == 0 {
-		newLen := len(result) - 1
-		result = result[:newLen]
+		if span.end > begin {
+			result = append(result, tokenSpan{begin: begin, end: span.end})
+		}
 	}
 	return result
 }
@@ -344,32 +563,49 @@ func separateTextWithStopWords(phrases [][]string) [][]string {
 // =================================================================================================
 // function separateHyphenedWords
 // brief description:
-//   Separate the hyphened words in candidate phrases into non-hyphened words for stemming later.
+//   Handle the hyphened words in candidate phrases according to a hyphen mode, e.g. separate them
+//   into non-hyphened words for stemming later.
 // input:
 //   phrases: A vector of candidate phrases.
+//   mode: How the hyphened words are handled.
 // output:
-//   The unhyphenated candidate phrases.
+//   The candidate phrases with their hyphened words handled. With HyphenBoth, a phrase that has
+//   hyphened words is followed by its joined variant.
 
-func separateHyphenedWords(phrases [][]string) [][]string {
+func separateHyphenedWords(phrases [][]string, mode HyphenMode) [][]string {
 	// --------------------------------------------------------------------------------------------
 	// step 1: Prepare the result
 	result := [][]string{}
+	if mode == HyphenKeep {
+		return append(result, phrases...)
+	}
 
 	// --------------------------------------------------------------------------------------------
-	// step 2: Separate the hyphened words in each candidate phrase
+	// step 2: Separate or join the hyphened words in each candidate phrase
 	for _, phrase := range phrases {
 		unhyphenatedPhrase := []string{}
+		joinedPhrase := []string{}
 		for _, word := range phrase {
 			if reHyphenedWords.MatchString(word) {
 				subwords := strings.Split(word, "-")
-				for _, subword := range subwords {
-					unhyphenatedPhrase = append(unhyphenatedPhrase, subword)
-				}
+				unhyphenatedPhrase = append(unhyphenatedPhrase, subwords...)
+				joinedPhrase = append(joinedPhrase, strings.Join(subwords, ""))
 			} else {
 				unhyphenatedPhrase = append(unhyphenatedPhrase, word)
+				joinedPhrase = append(joinedPhrase, word)
 			}
 		}
-		result = append(result, unhyphenatedPhrase)
+		switch mode {
+		case HyphenJoin:
+			result = append(result, joinedPhrase)
+		case HyphenBoth:
+			result = append(result, unhyphenatedPhrase)
+			if len(joinedPhrase) != len(unhyphenatedPhrase) {
+				result = append(result, joinedPhrase)
+			}
+		default:
+			result = append(result, unhyphenatedPhrase)
+		}
 	}
 
 	// --------------------------------------------------------------------------------------------
@@ -390,27 +626,7 @@ func separateHyphenedWords(phrases [][]string) [][]string {
 //   Porter, M. F. (2001). Snowball: A language for stemming algorithms.
 
 func stemPhrases(phrases [][]string) []string {
-	// --------------------------------------------------------------------------------------------
-	// step 1: Prepare the result
-	result := []string{}
-
-	// --------------------------------------------------------------------------------------------
-	// step 2: Stem the words in each candidate phrase
-	for _, phrase := range phrases {
-		stemmedPhrase := ""
-		for _, word := range phrase {
-			if len(stemmedPhrase) == 0 {
-				stemmedPhrase = english.Stem(word, false)
-			} else {
-				stemmedPhrase += " " + english.Stem(word, false)
-			}
-		}
-		result = append(result, stemmedPhrase)
-	}
-
-	// --------------------------------------------------------------------------------------------
-	// step 3: Return the result
-	return result
+	return defaultStemCache.stemPhrases(phrases)
 }
 
 // =================================================================================================
@@ -442,41 +658,60 @@ func StemPhrases(phrases []string) []string {
 // =================================================================================================
 // function ExtractKeyPhraseCandidates
 // brief description:
-//   Search from the input text for key phrase candidates.
+//   Search from the input text for key phrase candidates, using the default options.
 // input:
 //   text: The input text.
 // output:
 //   A vector of the stems of the key phrase candidates.
 
 func ExtractKeyPhraseCandidates(text string) []string {
-	// --------------------------------------------------------------------------------------------
-	// step 1: Tokenize the input text into words.
-	phrases := tokenizeIntoWords(text)
+	return defaultExtractor.ExtractKeyPhraseCandidates(text)
+}
 
-	// --------------------------------------------------------------------------------------------
-	// step 2: Convert roman numbers to arabic numbers, then convert non-abbreviation words to lower
-	//         case
-	for idxPhrase, phrase := range phrases {
-		for idxWord, word := range phrase {
-			convertedWord := convertNonAbbreviationToLowercase(convertRomanToArabic(word))
-			if convertedWord != word {
-				phrases[idxPhrase][idxWord] = convertedWord
-			}
-		}
-	}
+// =================================================================================================
+// function ExtractDocument
+// brief description:
+//   Process the input text with the default options, keeping its sentences, tokens and candidates.
+// input:
+//   text: The input text.
+// output:
+//   The processed document.
 
-	// --------------------------------------------------------------------------------------------
-	// step 3: Use stop words to separate words into candidate phrases
-	phrases = separateTextWithStopWords(phrases)
+func ExtractDocument(text string) *Document {
+	return defaultExtractor.ExtractDocument(text)
+}
 
-	// --------------------------------------------------------------------------------------------
-	// step 4: Seperate the hyphened words in the phrases for stemming later
-	phrases = separateHyphenedWords(phrases)
+// =================================================================================================
+// function ExtractKeyPhraseCandidatesWithConversions
+// brief description:
+//   Search from the input text for key phrase candidates using the default options, and report
+//   the conversions made on the way.
+// input:
+//   text: The input text.
+// output:
+//   A vector of the stems of the key phrase candidates, and the conversions, located in the text by
+//   their byte ranges. See Document.RestoreSurfaceForm to restore the candidates.
 
-	// --------------------------------------------------------------------------------------------
-	// step 5: Stem each phrase and return them
-	result := stemPhrases(phrases)
-	return result
+func ExtractKeyPhraseCandidatesWithConversions(text string) ([]string, []Conversion) {
+	return defaultExtractor.ExtractKeyPhraseCandidatesWithConversions(text)
+}
+
+// =================================================================================================
+// function nGramEnd
+// brief description:
+//   Get the end of the n-grams starting at a word, so that no n-gram is longer than a maximum.
+// input:
+//   begin: The index of the first word of the n-grams.
+//   numWords: The number of words.
+//   maxNGramLength: The maximum number of words of an n-gram, or 0 for no limit.
+// output:
+//   The index after the last word of the longest n-gram starting at begin.
+
+func nGramEnd(begin, numWords, maxNGramLength int) int {
+	if maxNGramLength > 0 && begin+maxNGramLength < numWords {
+		return begin + maxNGramLength
+	}
+	return numWords
 }
 
 // =================================================================================================
@@ -512,51 +747,67 @@ func GetAllPossiblePhrases(phrase string) []string {
 //	The term frequency
 
 func TF(phraseCandidates []string, auxPhrases []string) map[string]uint {
-	// --------------------------------------------------------------------------------------------
-	// step 1: initialize the result
-	result := map[string]uint{}
-	for _, candidate := range phraseCandidates {
-		words := strings.Split(candidate, " ")
-		numWords := len(words)
-		for i := 0; i < numWords; i++ {
-			text := words[i]
-			result[text] = 0
-			for j := i + 1; j < numWords; j++ {
-				text += " " + words[j]
-				result[text] = 0
-			}
-		}
-	}
+	return TFWithMode(phraseCandidates, auxPhrases, CountNested, 0)
+}
+
+// =================================================================================================
+// function TFWithMode
+// brief description:
+//	Compute Term Frequencies for a set of key phrase candidates with a set of auxiliary phrases, in
+//	a counting mode
+// input:
+//	phraseCandidates: a set of key phrase candidates
+//	auxPhrases: an array of auxiliary phrases
+//	mode: which n-grams are counted, and which occurrences are credited to them
+//	maxNGramLength: the maximum number of words of the n-grams counted, which keeps the memory
+//	                bounded on long unpunctuated text, or 0 for no limit
+// output:
+//	The term frequency
 
+func TFWithMode(phraseCandidates []string, auxPhrases []string, mode CountingMode,
+	maxNGramLength int) map[string]uint {
 	// --------------------------------------------------------------------------------------------
-	// step 2: scan through auxPhrases and compute the term frequencies
-	for _, auxPhrase := range auxPhrases {
-		auxWords := strings.Split(auxPhrase, " ")
-		numAuxWords := len(auxWords)
-		for i := 0; i < numAuxWords; i++ {
-			text := auxWords[i]
-			oldFreq, exists := result[text]
-			if !exists {
-				break
-			}
-			result[text] = oldFreq + 1
+	// step 1: encode the phrases with word IDs
+	vocabulary := NewVocabulary()
+	candidates := vocabulary.EncodeAll(phraseCandidates)
+	aux := vocabulary.EncodeAll(auxPhrases)
 
-			for j := i + 1; j < numAuxWords; j++ {
-				text += " " + auxWords[j]
-				oldFreq, exists = result[text]
-				if !exists {
-					break
-				}
-				result[text] = oldFreq + 1
-			}
-		}
-	}
+	// --------------------------------------------------------------------------------------------
+	// step 2: compute the term frequencies
+	counts := CountTFWithMode(candidates, aux, mode, maxNGramLength)
 
 	// --------------------------------------------------------------------------------------------
-	// step : return the result
+	// step 3: return the result keyed by texts
+	texts := map[NGramKey]string{}
+	vocabulary.countedTexts(candidates, mode, maxNGramLength, texts)
+	result := make(map[string]uint, len(counts))
+	for key, freq := range counts {
+		result[texts[key]] = freq
+	}
 	return result
 }
 
+// =================================================================================================
+// function candidateNGramKeys
+// brief description:
+//	Find the set of n-grams of a group of key phrase candidates
+// input:
+//	vocabulary: the vocabulary that encodes the candidates
+//	candidates: a group of key phrase candidates
+//	maxNGramLength: the maximum number of words of an n-gram, or 0 for no limit
+//	nGrams: the map that receives the word IDs of each n-gram by its key
+
+func candidateNGramKeys(vocabulary *Vocabulary, candidates []string, maxNGramLength int,
+	nGrams map[NGramKey][]WordID) {
+	for _, candidate := range candidates {
+		ids := vocabulary.Encode(candidate)
+		forEachNGram(ids, maxNGramLength, func(begin, end int, key NGramKey) bool {
+			nGrams[key] = ids[begin:end]
+			return true
+		})
+	}
+}
+
 // =================================================================================================
 // function IDF
 // brief description:
@@ -567,19 +818,42 @@ func TF(phraseCandidates []string, auxPhrases []string) map[string]uint {
 //	the inverse document frequencies
 
 func IDF(phraseCandidateGroups [][]string) map[string]float64 {
+	return IDFWithMode(phraseCandidateGroups, CountNested, 0)
+}
+
+// =================================================================================================
+// function IDFWithMode
+// brief description:
+//	Compute Inverse Document Frequencies from some sets of key phrase candidates, in a counting mode
+// input:
+//	phraseCandidateGroups: some groups of key phrase candidates
+//	mode: which n-grams are counted. As each candidate of a group is its own longest match,
+//	      CountExact and CountMaximal give the same document frequencies.
+//	maxNGramLength: the maximum number of words of the n-grams counted, or 0 for no limit
+// output:
+//	the inverse document frequencies
+
+func IDFWithMode(phraseCandidateGroups [][]string, mode CountingMode, maxNGramLength int) map[string]float64 {
 	// --------------------------------------------------------------------------------------------
-	// step 1: initialize the result
-	result := map[string]float64{}
+	// step 1: initialize the document frequency
+	vocabulary := NewVocabulary()
+	documentFrequency := map[NGramKey]float64{}
+	texts := map[NGramKey]string{}
 
 	// --------------------------------------------------------------------------------------------
 	// step 2: count the document frequency
+	type localResult struct {
+		documentFrequency map[NGramKey]float64
+		texts             map[NGramKey]string
+	}
 	numCPUs := runtime.NumCPU()
 	numGroups := len(phraseCandidateGroups)
 	chI := make(chan int)
-	chResult := make(chan map[string]float64)
+	chResult := make(chan localResult)
 	for idxCPU := 0; idxCPU < numCPUs; idxCPU++ {
 		go func() {
-			myResult := map[string]float64{}
+			myResult := localResult{map[NGramKey]float64{}, map[NGramKey]string{}}
+			groupResult := map[NGramKey][]WordID{}
 
 			for i0 := range chI {
 				i1 := i0 + 100
@@ -587,30 +861,18 @@ func IDF(phraseCandidateGroups [][]string) map[string]float64 {
 					i1 = numGroups
 				}
 				for i := i0; i < i1; i++ {
-					candidates := phraseCandidateGroups[i]
-
 					// first find the set of texts in this document
-					groupResult := map[string]bool{}
-					for _, candidate := range candidates {
-						words := strings.Split(candidate, " ")
-						numWords := len(words)
-						for i := 0; i < numWords; i++ {
-							text := words[i]
-							groupResult[text] = true
-							for j := i + 1; j < numWords; j++ {
-								text += " " + words[j]
-								groupResult[text] = true
-							}
-						}
+					for key := range groupResult {
+						delete(groupResult, key)
 					}
+					countedNGramKeys(vocabulary, phraseCandidateGroups[i], mode, maxNGramLength, groupResult)
 
 					// then update my document frequency with this set
-					for text, _ := range groupResult {
-						oldFreq, exists := myResult[text]
-						if !exists {
-							oldFreq = 0.0
+					for key, ids := range groupResult {
+						if _, exists := myResult.texts[key]; !exists {
+							myResult.texts[key] = vocabulary.Decode(ids)
 						}
-						myResult[text] = oldFreq + 1.0
+						myResult.documentFrequency[key] += 1.0
 					}
 				}
 			}
@@ -624,21 +886,21 @@ func IDF(phraseCandidateGroups [][]string) map[string]float64 {
 	close(chI)
 	for idxCPU := 0; idxCPU < numCPUs; idxCPU++ {
 		localResult := <-chResult
-		for phrase, freq := range localResult {
-			oldFreq, exists := result[phrase]
-			if !exists {
-				oldFreq = 0.0
-			}
-			result[phrase] = oldFreq + freq
+		for key, freq := range localResult.documentFrequency {
+			documentFrequency[key] += freq
+		}
+		for key, text := range localResult.texts {
+			texts[key] = text
 		}
 	}
 
 	// --------------------------------------------------------------------------------------------
 	// step 3: compute inverse document frequency from document frequency
 	n := len(phraseCandidateGroups)
-	for text, df := range result {
+	result := make(map[string]float64, len(documentFrequency))
+	for key, df := range documentFrequency {
 		idf := math.Log(float64(n) / df)
-		result[text] = idf
+		result[texts[key]] = idf
 	}
 
 	// --------------------------------------------------------------------------------------------
@@ -646,6 +908,51 @@ func IDF(phraseCandidateGroups [][]string) map[string]float64 {
 	return result
 }
 
+// =================================================================================================
+// function encodeSimilarity
+// brief description:
+//	Key the rows of a similarity source needed for the n-grams of some phrases by n-gram keys
+// input:
+//	vocabulary: the vocabulary that encodes the phrases to compare
+//	source: the similarity between strings
+//	phrases: the word IDs of the phrases whose n-grams are looked up
+//	maxNGramLength: the maximum number of words of an n-gram, or 0 for no limit
+// output:
+//	the similarity matrix keyed by n-gram keys, without the strings that have unknown words
+
+func encodeSimilarity(vocabulary *Vocabulary, source SimilaritySource, phrases [][]WordID,
+	maxNGramLength int) map[NGramKey]map[NGramKey]float64 {
+	result := map[NGramKey]map[NGramKey]float64{}
+	similarityMap, isMap := source.(SimilarityMap)
+	for _, phrase := range phrases {
+		forEachNGram(phrase, maxNGramLength, func(begin, end int, key1 NGramKey) bool {
+			if _, done := result[key1]; done {
+				return true
+			}
+			text1 := vocabulary.Decode(phrase[begin:end])
+			keyedRow := map[NGramKey]float64{}
+			visited := false
+			source.ForEachNeighbor(text1, func(text2 string, sim float64) bool {
+				visited = true
+				key2, exists := vocabulary.Key(text2)
+				if exists {
+					keyedRow[key2] = sim
+				}
+				return true
+			})
+			// a raw map has a row for a string even if the row is empty
+			if isMap {
+				_, visited = similarityMap[text1]
+			}
+			if visited {
+				result[key1] = keyedRow
+			}
+			return true
+		})
+	}
+	return result
+}
+
 // =================================================================================================
 // function SimTF
 // brief description:
@@ -659,61 +966,65 @@ func IDF(phraseCandidateGroups [][]string) map[string]float64 {
 
 func SimTF(phraseCandidates []string, auxPhrases []string,
 	phraseSimilarity map[string]map[string]float64) map[string]float64 {
+	return SimTFWith(phraseCandidates, auxPhrases, SimilarityMap(phraseSimilarity), 0)
+}
+
+// =================================================================================================
+// function SimTFWith
+// brief description:
+//	Compute Fuzzy Term Frequencies for a set of key phrase candidates with a set of auxiliary
+//	phrases, with any similarity source, e.g. a SimilarityMatrix
+// input:
+//	phraseCandidates: a set of key phrase candidates
+//	auxPhrases: an array of auxiliary phrases
+//	source: the similarity between strings
+//	maxNGramLength: the maximum number of words of the n-grams counted, or 0 for no limit
+// output:
+//	The term frequency
+
+func SimTFWith(phraseCandidates []string, auxPhrases []string, source SimilaritySource,
+	maxNGramLength int) map[string]float64 {
 	// --------------------------------------------------------------------------------------------
 	// step 1: initialize the result
-	result := map[string]float64{}
-	for _, candidate := range phraseCandidates {
-		words := strings.Split(candidate, " ")
-		numWords := len(words)
-		for i := 0; i < numWords; i++ {
-			text := words[i]
-			result[text] = 0
-			for j := i + 1; j < numWords; j++ {
-				text += " " + words[j]
-				result[text] = 0.0
-			}
-		}
+	vocabulary := NewVocabulary()
+	candidates := vocabulary.EncodeAll(phraseCandidates)
+	aux := vocabulary.EncodeAll(auxPhrases)
+	similarity := encodeSimilarity(vocabulary, source, aux, maxNGramLength)
+	frequency := map[NGramKey]float64{}
+	numWords := map[NGramKey]int{}
+	for _, candidate := range candidates {
+		forEachNGram(candidate, maxNGramLength, func(begin, end int, key NGramKey) bool {
+			frequency[key] = 0.0
+			numWords[key] = end - begin
+			return true
+		})
 	}
 
 	// --------------------------------------------------------------------------------------------
 	// step 2: scan through auxPhrases and compute the term frequencies
-	for _, auxPhrase := range auxPhrases {
-		auxWords := strings.Split(auxPhrase, " ")
-		numAuxWords := len(auxWords)
-		for i := 0; i < numAuxWords; i++ {
-			auxText := auxWords[i]
-			auxSim, exists := phraseSimilarity[auxText]
+	for _, auxPhrase := range aux {
+		forEachNGram(auxPhrase, maxNGramLength, func(begin, end int, key NGramKey) bool {
+			auxSim, exists := similarity[key]
 			if !exists {
-				continue
+				return false
 			}
-			for text, oldFreq := range result {
-				sim, exists := auxSim[text]
+			for simKey, sim := range auxSim {
+				oldFreq, exists := frequency[simKey]
 				if exists {
-					result[text] = oldFreq + sim
-				}
-			}
-
-			for j := i + 1; j < numAuxWords; j++ {
-				auxText += " " + auxWords[j]
-				auxSim, exists = phraseSimilarity[auxText]
-				if !exists {
-					break
-				}
-				for text, oldFreq := range result {
-					sim, exists := auxSim[text]
-					if exists {
-						result[text] = oldFreq + sim
-					}
+					frequency[simKey] = oldFreq + sim
 				}
 			}
-		}
+			return true
+		})
 	}
 
 	// --------------------------------------------------------------------------------------------
 	// step 3: rescale the result with numbers of words in phrase
-	for text, freq := range result {
-		words := strings.Split(text, " ")
-		result[text] = freq * float64(len(words))
+	texts := map[NGramKey]string{}
+	vocabulary.nGramTexts(candidates, maxNGramLength, texts)
+	result := make(map[string]float64, len(frequency))
+	for key, freq := range frequency {
+		result[texts[key]] = freq * float64(numWords[key])
 	}
 
 	// --------------------------------------------------------------------------------------------
@@ -732,88 +1043,74 @@ func SimTF(phraseCandidates []string, auxPhrases []string,
 //	the inverse document frequencies
 
 func SimIDF(phraseCandidateGroups [][]string, phraseSimilarity map[string]map[string]float64) map[string]float64 {
+	return SimIDFWith(phraseCandidateGroups, SimilarityMap(phraseSimilarity), 0)
+}
+
+// =================================================================================================
+// function SimIDFWith
+// brief description:
+//	Compute Fuzzy Inverse Document Frequencies from some sets of key phrase candidates, with any
+//	similarity source, e.g. a SimilarityMatrix
+// input:
+//	phraseCandidateGroups: some groups of key phrase candidates
+//	source: the similarity between strings
+//	maxNGramLength: the maximum number of words of the n-grams counted, or 0 for no limit
+// output:
+//	the inverse document frequencies
+
+func SimIDFWith(phraseCandidateGroups [][]string, source SimilaritySource, maxNGramLength int) map[string]float64 {
 	// --------------------------------------------------------------------------------------------
 	// step 1: initialize the result
-	result := map[string]float64{}
-	for _, candidates := range phraseCandidateGroups {
-		for _, candidate := range candidates {
-			words := strings.Split(candidate, " ")
-			numWords := len(words)
-			for i := 0; i < numWords; i++ {
-				text := words[i]
-				result[text] = 0.0
-				for j := i + 1; j < numWords; j++ {
-					text += " " + words[j]
-					result[text] = 0.0
-				}
-			}
+	vocabulary := NewVocabulary()
+	groups := make([][][]WordID, len(phraseCandidateGroups))
+	allCandidates := [][]WordID{}
+	documentFrequency := map[NGramKey]float64{}
+	for idxGroup, candidates := range phraseCandidateGroups {
+		groups[idxGroup] = vocabulary.EncodeAll(candidates)
+		allCandidates = append(allCandidates, groups[idxGroup]...)
+		for _, candidate := range groups[idxGroup] {
+			forEachNGram(candidate, maxNGramLength, func(begin, end int, key NGramKey) bool {
+				documentFrequency[key] = 0.0
+				return true
+			})
 		}
 	}
+	similarity := encodeSimilarity(vocabulary, source, allCandidates, maxNGramLength)
 
 	// --------------------------------------------------------------------------------------------
 	// step 2: count the document frequency
-	for idxGroup, candidates := range phraseCandidateGroups {
+	for idxGroup, candidates := range groups {
 		// first initialize groupResult to those in candidates and those similar to the candidates
-		groupResult := map[string]float64{}
+		groupResult := map[NGramKey]float64{}
 		for _, candidate := range candidates {
-			words := strings.Split(candidate, " ")
-			numWords := len(words)
-			for i := 0; i < numWords; i++ {
-				text := words[i]
-				groupResult[text] = 0.0
-				simTexts, simExists := phraseSimilarity[text]
-				if simExists {
-					for simText, _ := range simTexts {
-						_, resultExists := result[simText]
-						if resultExists {
-							groupResult[simText] = 0.0
-						}
+			forEachNGram(candidate, maxNGramLength, func(begin, end int, key NGramKey) bool {
+				groupResult[key] = 0.0
+				for simKey := range similarity[key] {
+					_, resultExists := documentFrequency[simKey]
+					if resultExists {
+						groupResult[simKey] = 0.0
 					}
 				}
-				for j := i + 1; j < numWords; j++ {
-					text += " " + words[j]
-					groupResult[text] = 0.0
-					simTexts, simExists = phraseSimilarity[text]
-					if simExists {
-						for simText, _ := range simTexts {
-							_, resultExists := result[simText]
-							if resultExists {
-								groupResult[simText] = 0.0
-							}
-						}
-					}
-				}
-			}
+				return true
+			})
 		}
 
 		// then find the set of texts in this document
 		for _, candidate := range candidates {
-			words := strings.Split(candidate, " ")
-			numWords := len(words)
-
-			for i := 0; i < numWords; i++ {
-				text1 := words[i]
-				for text2, oldValue := range groupResult {
-					sim, exists := phraseSimilarity[text1][text2]
+			forEachNGram(candidate, maxNGramLength, func(begin, end int, key NGramKey) bool {
+				for simKey, sim := range similarity[key] {
+					oldValue, exists := groupResult[simKey]
 					if exists {
-						groupResult[text2] = math.Max(oldValue, sim)
-					}
-				}
-				for j := i + 1; j < numWords; j++ {
-					text1 += " " + words[j]
-					for text2, oldValue := range groupResult {
-						sim, exists := phraseSimilarity[text1][text2]
-						if exists {
-							groupResult[text2] = math.Max(oldValue, sim)
-						}
+						groupResult[simKey] = math.Max(oldValue, sim)
 					}
 				}
-			}
+				return true
+			})
 		}
 
 		// then update the document frequency with this set
-		for text, value := range groupResult {
-			result[text] += value
+		for key, value := range groupResult {
+			documentFrequency[key] += value
 		}
 
 		if (idxGroup+1)%1000 == 0 {
@@ -823,10 +1120,15 @@ func SimIDF(phraseCandidateGroups [][]string, phraseSimilarity map[string]map[st
 
 	// --------------------------------------------------------------------------------------------
 	// step 3: compute inverse document frequency from document frequency
+	texts := map[NGramKey]string{}
+	for _, candidates := range groups {
+		vocabulary.nGramTexts(candidates, maxNGramLength, texts)
+	}
 	n := len(phraseCandidateGroups)
-	for text, df := range result {
+	result := make(map[string]float64, len(documentFrequency))
+	for key, df := range documentFrequency {
 		idf := math.Log(float64(n) / df)
-		result[text] = idf
+		result[texts[key]] = idf
 	}
 
 	// --------------------------------------------------------------------------------------------
@@ -851,73 +1153,28 @@ func ArgSort(a map[string]float64) []string {
 }
 
 // ================================================================================================
-// func Includes
-func Includes(text1, text2 string) bool {
-	words1 := strings.Split(text1, " ")
-	words2 := strings.Split(text2, " ")
-	n1 := len(words1)
-	n2 := len(words2)
-	if n1 < n2 {
-		return false
-	}
-	for i := 0; i+n2 <= n1; i++ {
-		matched := true
-		for j := 0; j < n2; j++ {
-			if words1[i+j] != words2[j] {
-				matched = false
-				break
-			}
-		}
-		if matched {
-			return true
+// func appendWords
+func appendWords(words []string, text string) []string {
+	for {
+		word, rest, found := strings.Cut(text, " ")
+		words = append(words, word)
+		if !found {
+			return words
 		}
+		text = rest
 	}
-	return false
+}
+
+// ================================================================================================
+// func Includes
+func Includes(text1, text2 string) bool {
+	var buffer1, buffer2 [16]string
+	return includesSequence(appendWords(buffer1[:0], text1), appendWords(buffer2[:0], text2))
 }
 
 // ================================================================================================
 // func Overlaps
 func Overlaps(text1, text2 string) bool {
-	// --------------------------------------------------------------------------------------------
-	// step 1: split the texts
-	words1 := strings.Split(text1, " ")
-	words2 := strings.Split(text2, " ")
-
-	// --------------------------------------------------------------------------------------------
-	// step 2: check the sizes
-	n1 := len(words1)
-	n2 := len(words2)
-	if n2 > n1 {
-		n1, n2 = n2, n1
-		words1, words2 = words2, words1
-	}
-
-	// --------------------------------------------------------------------------------------------
-	// step 3: find the intersection
-	for k := 1; k <= n2; k++ {
-		// (3.1) check if overlaps with words2 before words1
-		matched := true
-		for j := 0; j < k; j++ {
-			if words1[j] != words2[n2-k+j] {
-				matched = false
-				break
-			}
-		}
-		if matched {
-			return true
-		}
-
-		// (3.2) check if overlaps with words1 before words2
-		matched = true
-		for j := 0; j < k; j++ {
-			if words1[n1-k+j] != words2[j] {
-				matched = false
-				break
-			}
-		}
-		if matched {
-			return true
-		}
-	}
-	return false
+	var buffer1, buffer2 [16]string
+	return overlapsSequence(appendWords(buffer1[:0], text1), appendWords(buffer2[:0], text2))
 }
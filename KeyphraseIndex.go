@@ -0,0 +1,811 @@
+package KeyphraseExtraction
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// keyphraseIndexVersion is the version of the format written by KeyphraseIndex.WriteTo.
+const keyphraseIndexVersion = 1
+
+// keyphraseIndexLogHeader is the beginning of the log of a stored KeyphraseIndex.
+const keyphraseIndexLogHeader = "KeyphraseExtraction index log 1\n"
+
+// maxIndexRecordSize is the largest record of the log of a stored KeyphraseIndex, so that a
+// corrupted length does not allocate without bound.
+const maxIndexRecordSize = 1 << 30
+
+// Posting is a document that has a keyphrase of a KeyphraseIndex.
+type Posting struct {
+	// Document is the ID of the document.
+	Document string
+
+	// Score is the score of the keyphrase in the document, higher is better.
+	Score float64
+
+	// Offsets are the byte offsets of the occurrences of the keyphrase in the text of the document,
+	// if it has been indexed with its processed document.
+	Offsets []int
+}
+
+// KeyphraseIndex maps stemmed keyphrases to the documents that have them, with their scores and
+// positions, so that the documents can be searched by keyphrases. It is safe for concurrent use.
+// It is kept in memory, and an index opened with OpenKeyphraseIndex also appends each change to a
+// log on disk.
+type KeyphraseIndex struct {
+	mutex sync.RWMutex
+
+	// store is the log of the index on disk, or nil for an index kept in memory only
+	store *indexStore
+
+	// postings are the documents of each phrase
+	postings map[string][]Posting
+
+	// phrases are the phrases of each document, and phrasesOfWord the phrases of each stemmed word
+	phrases       map[string][]string
+	phrasesOfWord map[string]map[string]bool
+
+	// acronyms are the long forms of the acronyms defined in the documents, so that a query can use
+	// them
+	acronyms map[string]string
+}
+
+// indexSnapshot is the content of a KeyphraseIndex as it is written by WriteTo.
+type indexSnapshot struct {
+	Version  int
+	Postings map[string][]Posting
+	Acronyms map[string]string
+}
+
+// indexRecord is a record of the log of a stored KeyphraseIndex: the postings of a document, which
+// replace its former ones, without the Document of the postings, and the acronyms it defines. A
+// record without postings removes the document.
+type indexRecord struct {
+	Document string
+	Postings map[string]Posting
+	Acronyms map[string]string
+}
+
+// indexStore is the log of a stored KeyphraseIndex, opened for appending.
+type indexStore struct {
+	path string
+	file *os.File
+
+	// size is the size of the log, and numRecords its number of records
+	size       int64
+	numRecords int
+}
+
+// SearchOptions controls the search of a KeyphraseIndex.
+type SearchOptions struct {
+	// Extractor normalizes the query as the indexed documents have been, e.g. "state-of-the-art" or
+	// "3.50". nil means the extractor of ExtractKeyPhraseCandidates.
+	Extractor *Extractor
+
+	// Similarity expands the query with its similar phrases, e.g. a SimilarityMatrix. nil means
+	// no expansion by similarity.
+	Similarity SimilaritySource
+
+	// MinSimilarity is the lowest similarity of the phrases the query is expanded with.
+	MinSimilarity float64
+
+	// Includes expands the query with the indexed phrases that include it, so that "network" also
+	// finds "neural network". The similarity of such a phrase is the ratio of their numbers of
+	// words.
+	Includes bool
+
+	// TopK is the largest number of documents returned. 0 means no limit.
+	TopK int
+}
+
+// SearchMatch is a phrase of a document that matches a query.
+type SearchMatch struct {
+	// Phrase is the stemmed phrase, and Similarity its similarity to the query.
+	Phrase     string
+	Similarity float64
+
+	// Score and Offsets are those of the phrase in the document.
+	Score   float64
+	Offsets []int
+}
+
+// SearchResult is a document found by a search.
+type SearchResult struct {
+	// Document is the ID of the document.
+	Document string
+
+	// Score is the sum of the scores of the matching phrases, weighted by their similarities to the
+	// query.
+	Score float64
+
+	// Matches are the phrases of the document that match the query, by decreasing weighted score.
+	Matches []SearchMatch
+}
+
+// =================================================================================================
+// function NewKeyphraseIndex
+// brief description:
+//   Create an empty keyphrase index.
+// output:
+//   the new index
+
+func NewKeyphraseIndex() *KeyphraseIndex {
+	return &KeyphraseIndex{
+		postings:      map[string][]Posting{},
+		phrases:       map[string][]string{},
+		phrasesOfWord: map[string]map[string]bool{},
+		acronyms:      map[string]string{},
+	}
+}
+
+// =================================================================================================
+// method KeyphraseIndex.addPosting
+// brief description:
+//   Add a posting of a phrase. The caller holds the lock.
+// input:
+//   phrase: the stemmed phrase
+//   posting: the document that has the phrase
+
+func (index *KeyphraseIndex) addPosting(phrase string, posting Posting) {
+	if _, exists := index.postings[phrase]; !exists {
+		for _, word := range strings.Split(phrase, " ") {
+			if index.phrasesOfWord[word] == nil {
+				index.phrasesOfWord[word] = map[string]bool{}
+			}
+			index.phrasesOfWord[word][phrase] = true
+		}
+	}
+	index.postings[phrase] = append(index.postings[phrase], posting)
+	index.phrases[posting.Document] = append(index.phrases[posting.Document], phrase)
+}
+
+// =================================================================================================
+// method KeyphraseIndex.remove
+// brief description:
+//   Remove a document. The caller holds the lock.
+// input:
+//   id: the ID of the document
+
+func (index *KeyphraseIndex) remove(id string) {
+	for _, phrase := range index.phrases[id] {
+		postings := index.postings[phrase][:0]
+		for _, posting := range index.postings[phrase] {
+			if posting.Document != id {
+				postings = append(postings, posting)
+			}
+		}
+		if len(postings) > 0 {
+			index.postings[phrase] = postings
+			continue
+		}
+		delete(index.postings, phrase)
+		for _, word := range strings.Split(phrase, " ") {
+			delete(index.phrasesOfWord[word], phrase)
+			if len(index.phrasesOfWord[word]) == 0 {
+				delete(index.phrasesOfWord, word)
+			}
+		}
+	}
+	delete(index.phrases, id)
+}
+
+// =================================================================================================
+// method KeyphraseIndex.apply
+// brief description:
+//   Replace the postings of a document with those of a record. The caller holds the lock.
+// input:
+//   record: the postings and the acronyms of the document
+
+func (index *KeyphraseIndex) apply(record indexRecord) {
+	for acronym, longForm := range record.Acronyms {
+		index.acronyms[acronym] = longForm
+	}
+	index.remove(record.Document)
+	for phrase, posting := range record.Postings {
+		posting.Document = record.Document
+		index.addPosting(phrase, posting)
+	}
+}
+
+// =================================================================================================
+// method KeyphraseIndex.commit
+// brief description:
+//   Append a record to the log of a stored index, then apply it, compacting the log once most of
+//   its records are out of date. The caller holds the lock.
+// input:
+//   record: the postings and the acronyms of a document
+// output:
+//   the error if any, in which case the record is not applied
+
+func (index *KeyphraseIndex) commit(record indexRecord) error {
+	if index.store == nil {
+		index.apply(record)
+		return nil
+	}
+	if err := index.store.append(record); err != nil {
+		return err
+	}
+	index.apply(record)
+	if index.store.numRecords >= 1000 && index.store.numRecords > 2*len(index.phrases) {
+		return index.compact()
+	}
+	return nil
+}
+
+// =================================================================================================
+// method KeyphraseIndex.Add
+// brief description:
+//   Index the keyphrases of a document, replacing those of a document with the same ID.
+// input:
+//   id: the ID of the document
+//   document: the processed document, which gives the offsets of the keyphrases and the acronyms
+//             the queries can use, or nil
+//   scores: the score of each stemmed keyphrase of the document, e.g. from CandidateScores. nil
+//           indexes every candidate of the document with its number of occurrences as its score.
+// output:
+//   the error of the log of a stored index if any, in which case the index is left unchanged
+// notes:
+//   An acronym defined in a document stays known after the document is removed.
+
+func (index *KeyphraseIndex) Add(id string, document *Document, scores map[string]float64) error {
+	// --------------------------------------------------------------------------------------------
+	// step 1: find the offsets of the candidates
+	offsets := map[string][]int{}
+	if document != nil {
+		for _, candidate := range document.Candidates {
+			offset := -1
+			if candidate.Begin < candidate.End {
+				offset = document.Tokens[candidate.Begin].Offset
+			}
+			offsets[candidate.Phrase] = append(offsets[candidate.Phrase], offset)
+		}
+	}
+	if scores == nil {
+		scores = make(map[string]float64, len(offsets))
+		for phrase, phraseOffsets := range offsets {
+			scores[phrase] = float64(len(phraseOffsets))
+		}
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 2: replace the postings of the document
+	record := indexRecord{Document: id, Postings: make(map[string]Posting, len(scores)),
+		Acronyms: map[string]string{}}
+	if document != nil {
+		for _, conversion := range document.Conversions {
+			if conversion.Kind == ConversionAcronymExpansion {
+				record.Acronyms[conversion.Original] = conversion.Converted
+			}
+		}
+	}
+	for phrase, score := range scores {
+		record.Postings[phrase] = Posting{Score: score, Offsets: offsets[phrase]}
+	}
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+	return index.commit(record)
+}
+
+// =================================================================================================
+// method KeyphraseIndex.Remove
+// brief description:
+//   Remove a document from the index.
+// input:
+//   id: the ID of the document
+// output:
+//   the error of the log of a stored index if any, in which case the index is left unchanged
+
+func (index *KeyphraseIndex) Remove(id string) error {
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+	if _, exists := index.phrases[id]; !exists {
+		return nil
+	}
+	return index.commit(indexRecord{Document: id})
+}
+
+// =================================================================================================
+// method KeyphraseIndex.NumDocuments
+// brief description:
+//   Get the number of documents in the index.
+// output:
+//   the number of documents
+
+func (index *KeyphraseIndex) NumDocuments() int {
+	index.mutex.RLock()
+	defer index.mutex.RUnlock()
+	return len(index.phrases)
+}
+
+// =================================================================================================
+// method KeyphraseIndex.NumPhrases
+// brief description:
+//   Get the number of distinct phrases in the index.
+// output:
+//   the number of phrases
+
+func (index *KeyphraseIndex) NumPhrases() int {
+	index.mutex.RLock()
+	defer index.mutex.RUnlock()
+	return len(index.postings)
+}
+
+// =================================================================================================
+// method KeyphraseIndex.Postings
+// brief description:
+//   Get the documents that have a stemmed phrase.
+// input:
+//   phrase: the stemmed phrase
+// output:
+//   a copy of the postings of the phrase
+
+func (index *KeyphraseIndex) Postings(phrase string) []Posting {
+	index.mutex.RLock()
+	defer index.mutex.RUnlock()
+	return append([]Posting(nil), index.postings[phrase]...)
+}
+
+// =================================================================================================
+// method KeyphraseIndex.expand
+// brief description:
+//   Find the indexed phrases that match a stemmed query. The caller holds the lock.
+// input:
+//   query: the stemmed query
+//   options: the options of the search
+// output:
+//   the similarity to the query of each matching phrase
+
+func (index *KeyphraseIndex) expand(query string, options SearchOptions) map[string]float64 {
+	result := map[string]float64{}
+	match := func(phrase string, similarity float64) {
+		if _, indexed := index.postings[phrase]; indexed && similarity > result[phrase] {
+			result[phrase] = similarity
+		}
+	}
+	match(query, 1.0)
+	if options.Similarity != nil {
+		options.Similarity.ForEachNeighbor(query, func(neighbor string, similarity float64) bool {
+			if similarity >= options.MinSimilarity {
+				match(neighbor, similarity)
+			}
+			return true
+		})
+	}
+	if options.Includes {
+		// the phrases that include the query have its rarest word
+		words := strings.Split(query, " ")
+		rarest := index.phrasesOfWord[words[0]]
+		for _, word := range words[1:] {
+			if len(index.phrasesOfWord[word]) < len(rarest) {
+				rarest = index.phrasesOfWord[word]
+			}
+		}
+		for phrase := range rarest {
+			if Includes(phrase, query) {
+				match(phrase, float64(len(words))/float64(strings.Count(phrase, " ")+1))
+			}
+		}
+	}
+	return result
+}
+
+// =================================================================================================
+// method KeyphraseIndex.normalizeQuery
+// brief description:
+//   Stem a query as the words of the indexed documents. The caller holds the lock.
+// input:
+//   query: the query
+//   extractor: the extractor of the indexed documents
+// output:
+//   the stemmed query, whose acronyms defined in the documents are replaced with their long forms
+
+func (index *KeyphraseIndex) normalizeQuery(query string, extractor *Extractor) string {
+	words := strings.Fields(query)
+	for i, word := range words {
+		if longForm, defined := index.acronyms[normalizeAcronym(word)]; defined {
+			words[i] = longForm
+		}
+	}
+	document := extractor.ExtractDocument(strings.Join(words, " "))
+	stems := make([]string, len(document.Tokens))
+	for i, tok := range document.Tokens {
+		stems[i] = tok.Stem
+	}
+	return strings.Join(stems, " ")
+}
+
+// =================================================================================================
+// method KeyphraseIndex.Search
+// brief description:
+//   Find the documents that have a keyphrase, or phrases similar to it.
+// input:
+//   query: the keyphrase, which is normalized, with its hyphens, numbers and acronyms, and stemmed
+//          as the documents
+//   options: the options of the search
+// output:
+//   the documents found, ranked by their scores, then by their IDs
+
+func (index *KeyphraseIndex) Search(query string, options SearchOptions) []SearchResult {
+	// --------------------------------------------------------------------------------------------
+	// step 1: normalize and expand the query
+	result := []SearchResult{}
+	extractor := options.Extractor
+	if extractor == nil {
+		extractor = defaultExtractor
+	}
+	index.mutex.RLock()
+	defer index.mutex.RUnlock()
+	stemmed := index.normalizeQuery(query, extractor)
+	if stemmed == "" {
+		return result
+	}
+	phrases := index.expand(stemmed, options)
+
+	// --------------------------------------------------------------------------------------------
+	// step 2: collect the matches of each document
+	byDocument := map[string]*SearchResult{}
+	for phrase, similarity := range phrases {
+		for _, posting := range index.postings[phrase] {
+			found, exists := byDocument[posting.Document]
+			if !exists {
+				found = &SearchResult{Document: posting.Document}
+				byDocument[posting.Document] = found
+			}
+			found.Score += similarity * posting.Score
+			found.Matches = append(found.Matches, SearchMatch{phrase, similarity, posting.Score, posting.Offsets})
+		}
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 3: rank the documents and their matches
+	for _, found := range byDocument {
+		matches := found.Matches
+		sort.Slice(matches, func(i, j int) bool {
+			scoreI, scoreJ := matches[i].Similarity*matches[i].Score, matches[j].Similarity*matches[j].Score
+			if scoreI != scoreJ {
+				return scoreI > scoreJ
+			}
+			return matches[i].Phrase < matches[j].Phrase
+		})
+		result = append(result, *found)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Score != result[j].Score {
+			return result[i].Score > result[j].Score
+		}
+		return result[i].Document < result[j].Document
+	})
+	if options.TopK > 0 && len(result) > options.TopK {
+		result = result[:options.TopK]
+	}
+	return result
+}
+
+// =================================================================================================
+// method KeyphraseIndex.WriteTo
+// brief description:
+//   Write the postings of the index, so that they can be read back with ReadFrom.
+// input:
+//   writer: the writer
+// output:
+//   the number of bytes written, and the error if any
+
+func (index *KeyphraseIndex) WriteTo(writer io.Writer) (int64, error) {
+	index.mutex.RLock()
+	defer index.mutex.RUnlock()
+	counter := &countingWriter{writer: bufio.NewWriter(writer)}
+	if err := gob.NewEncoder(counter).Encode(indexSnapshot{keyphraseIndexVersion, index.postings, index.acronyms}); err != nil {
+		return counter.count, err
+	}
+	return counter.count, counter.writer.Flush()
+}
+
+// =================================================================================================
+// method KeyphraseIndex.ReadFrom
+// brief description:
+//   Replace the content of the index with postings written by WriteTo, rewriting the log of a
+//   stored index.
+// input:
+//   reader: the reader
+// output:
+//   the number of bytes read, and the error if any
+
+func (index *KeyphraseIndex) ReadFrom(reader io.Reader) (int64, error) {
+	counter := &countingReader{reader: bufio.NewReader(reader)}
+	var snapshot indexSnapshot
+	if err := gob.NewDecoder(counter).Decode(&snapshot); err != nil {
+		return counter.count, fmt.Errorf("KeyphraseExtraction: keyphrase index: %v", err)
+	}
+	if snapshot.Version != keyphraseIndexVersion {
+		return counter.count, fmt.Errorf("KeyphraseExtraction: keyphrase index version %d is not supported", snapshot.Version)
+	}
+	loaded := NewKeyphraseIndex()
+	for acronym, longForm := range snapshot.Acronyms {
+		loaded.acronyms[acronym] = longForm
+	}
+	for phrase, postings := range snapshot.Postings {
+		for _, posting := range postings {
+			loaded.addPosting(phrase, posting)
+		}
+	}
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+	index.postings = loaded.postings
+	index.phrases = loaded.phrases
+	index.phrasesOfWord = loaded.phrasesOfWord
+	index.acronyms = loaded.acronyms
+	if index.store != nil {
+		return counter.count, index.compact()
+	}
+	return counter.count, nil
+}
+
+// =================================================================================================
+// function encodeIndexRecord
+// brief description:
+//   Encode a record of the log of a stored index, with its length and its checksum.
+// input:
+//   record: the record
+// output:
+//   the bytes of the record, and the error if any
+
+func encodeIndexRecord(record indexRecord) ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.Write(make([]byte, 8))
+	if err := gob.NewEncoder(&buffer).Encode(record); err != nil {
+		return nil, err
+	}
+	data := buffer.Bytes()
+	binary.LittleEndian.PutUint32(data[0:], uint32(len(data)-8))
+	binary.LittleEndian.PutUint32(data[4:], crc32.ChecksumIEEE(data[8:]))
+	return data, nil
+}
+
+// =================================================================================================
+// method indexStore.append
+// brief description:
+//   Append a record to the log, removing what has been written of it if the write fails.
+// input:
+//   record: the record
+// output:
+//   the error if any
+
+func (store *indexStore) append(record indexRecord) error {
+	data, err := encodeIndexRecord(record)
+	if err != nil {
+		return err
+	}
+	if _, err := store.file.Write(data); err != nil {
+		if truncateErr := store.file.Truncate(store.size); truncateErr == nil {
+			store.file.Seek(store.size, io.SeekStart)
+		}
+		return err
+	}
+	store.size += int64(len(data))
+	store.numRecords++
+	return nil
+}
+
+// =================================================================================================
+// method KeyphraseIndex.replay
+// brief description:
+//   Apply the records of a log to an empty index, up to the first record that is cut short or
+//   corrupted, e.g. by a crash while it was appended.
+// input:
+//   reader: the reader of the log, after its header
+// output:
+//   the size of the valid records, their number, and the error if any
+
+func (index *KeyphraseIndex) replay(reader io.Reader) (int64, int, error) {
+	counter := &countingReader{reader: bufio.NewReader(reader)}
+	var size int64
+	numRecords := 0
+	var header [8]byte
+	for {
+		if _, err := io.ReadFull(counter, header[:]); err == io.EOF || err == io.ErrUnexpectedEOF {
+			return size, numRecords, nil
+		} else if err != nil {
+			return size, numRecords, err
+		}
+		length := binary.LittleEndian.Uint32(header[0:])
+		if length > maxIndexRecordSize {
+			return size, numRecords, nil
+		}
+		data := make([]byte, length)
+		if _, err := io.ReadFull(counter, data); err == io.EOF || err == io.ErrUnexpectedEOF {
+			return size, numRecords, nil
+		} else if err != nil {
+			return size, numRecords, err
+		}
+		if crc32.ChecksumIEEE(data) != binary.LittleEndian.Uint32(header[4:]) {
+			return size, numRecords, nil
+		}
+		var record indexRecord
+		if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&record); err != nil {
+			return size, numRecords, fmt.Errorf("KeyphraseExtraction: keyphrase index record %d: %v", numRecords, err)
+		}
+		index.apply(record)
+		size = counter.count
+		numRecords++
+	}
+}
+
+// =================================================================================================
+// function OpenKeyphraseIndex
+// brief description:
+//   Open an index stored in a local file, creating the file if it does not exist. Each change of
+//   the index is then appended to the file.
+// input:
+//   path: the path of the file
+// output:
+//   the index, which must be closed with Close, and the error if any
+// notes:
+//   The file is a log of the documents added and removed. The changes are written to the file
+//   when they are made, and to the disk by Sync and Close, so that a crash can only lose the
+//   changes since the last Sync. A record cut short by a crash is dropped when the file is opened
+//   again. The log is rewritten with one record per document once it has more than twice as many
+//   records as documents, and at least 1000, or by Compact.
+
+func OpenKeyphraseIndex(path string) (*KeyphraseIndex, error) {
+	// --------------------------------------------------------------------------------------------
+	// step 1: open the file and check its header, writing it if the file is new
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, len(keyphraseIndexLogHeader))
+	_, err = io.ReadFull(file, header)
+	switch {
+	case err == io.EOF:
+		_, err = file.Write([]byte(keyphraseIndexLogHeader))
+	case err == io.ErrUnexpectedEOF, err == nil && string(header) != keyphraseIndexLogHeader:
+		err = fmt.Errorf("KeyphraseExtraction: %s is not a keyphrase index", path)
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 2: replay the records, and drop a last record cut short
+	index := NewKeyphraseIndex()
+	size, numRecords, err := index.replay(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	size += int64(len(keyphraseIndexLogHeader))
+	if err := file.Truncate(size); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if _, err := file.Seek(size, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+	index.store = &indexStore{path: path, file: file, size: size, numRecords: numRecords}
+	return index, nil
+}
+
+// =================================================================================================
+// method KeyphraseIndex.compact
+// brief description:
+//   Rewrite the log of a stored index with one record per document, replacing the file only once
+//   the new log is fully written. The caller holds the lock.
+// output:
+//   the error if any, in which case the former log is kept
+
+func (index *KeyphraseIndex) compact() error {
+	// --------------------------------------------------------------------------------------------
+	// step 1: write the acronyms, then the postings of each document, to a new file
+	store := index.store
+	file, err := os.CreateTemp(filepath.Dir(store.path), filepath.Base(store.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	compacted := &indexStore{path: store.path, file: file}
+	err = func() error {
+		n, err := file.Write([]byte(keyphraseIndexLogHeader))
+		compacted.size = int64(n)
+		if err != nil {
+			return err
+		}
+		if err := compacted.append(indexRecord{Acronyms: index.acronyms}); err != nil {
+			return err
+		}
+		for id, phrases := range index.phrases {
+			record := indexRecord{Document: id, Postings: make(map[string]Posting, len(phrases))}
+			for _, phrase := range phrases {
+				for _, posting := range index.postings[phrase] {
+					if posting.Document == id {
+						record.Postings[phrase] = Posting{Score: posting.Score, Offsets: posting.Offsets}
+					}
+				}
+			}
+			if err := compacted.append(record); err != nil {
+				return err
+			}
+		}
+		return file.Sync()
+	}()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 2: replace the log with the new file, which stays open for appending
+	if err := os.Rename(file.Name(), store.path); err != nil {
+		file.Close()
+		return err
+	}
+	store.file.Close()
+	index.store = compacted
+	return nil
+}
+
+// =================================================================================================
+// method KeyphraseIndex.Compact
+// brief description:
+//   Rewrite the log of a stored index with one record per document.
+// output:
+//   the error if any
+
+func (index *KeyphraseIndex) Compact() error {
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+	if index.store == nil {
+		return nil
+	}
+	return index.compact()
+}
+
+// =================================================================================================
+// method KeyphraseIndex.Sync
+// brief description:
+//   Write the changes of a stored index to the disk.
+// output:
+//   the error if any
+
+func (index *KeyphraseIndex) Sync() error {
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+	if index.store == nil {
+		return nil
+	}
+	return index.store.file.Sync()
+}
+
+// =================================================================================================
+// method KeyphraseIndex.Close
+// brief description:
+//   Write the changes of a stored index to the disk and close its file. The index is then kept in
+//   memory only.
+// output:
+//   the error if any
+
+func (index *KeyphraseIndex) Close() error {
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+	if index.store == nil {
+		return nil
+	}
+	file := index.store.file
+	index.store = nil
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,196 @@
+package KeyphraseExtraction
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestKeyphraseIndex() *KeyphraseIndex {
+	index := NewKeyphraseIndex()
+	for _, document := range []struct{ id, text string }{
+		{"cnn", "Convolutional neural networks (CNNs) classify images. CNNs are deep."},
+		{"sota", "State-of-the-art methods beat the baselines."},
+		{"gnn", "Graph neural networks learn on graphs."},
+	} {
+		index.Add(document.id, acronymExtractor.ExtractDocument(document.text), nil)
+	}
+	return index
+}
+
+func searchDocuments(index *KeyphraseIndex, query string, options SearchOptions) []string {
+	result := []string{}
+	for _, found := range index.Search(query, options) {
+		result = append(result, found.Document)
+	}
+	return result
+}
+
+func TestKeyphraseIndexSearch(t *testing.T) {
+	index := newTestKeyphraseIndex()
+	if index.NumDocuments() != 3 {
+		t.Fatalf("NumDocuments = %d, want 3", index.NumDocuments())
+	}
+	for _, test := range []struct {
+		query   string
+		options SearchOptions
+		want    []string
+	}{
+		{"state-of-the-art", SearchOptions{Includes: true}, []string{"sota"}},
+		{"State-of-the-art methods", SearchOptions{Includes: true}, []string{"sota"}},
+		{"CNNs", SearchOptions{}, []string{"cnn"}},
+		{"convolutional neural networks", SearchOptions{}, []string{"cnn"}},
+		{"neural networks", SearchOptions{}, []string{}},
+		{"neural networks", SearchOptions{Includes: true}, []string{"cnn", "gnn"}},
+		{"neural networks", SearchOptions{Includes: true, TopK: 1}, []string{"cnn"}},
+		{" . ", SearchOptions{}, []string{}},
+	} {
+		got := searchDocuments(index, test.query, test.options)
+		if len(got) != len(test.want) {
+			t.Errorf("Search(%q, %+v) = %q, want %q", test.query, test.options, got, test.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("Search(%q, %+v) = %q, want %q", test.query, test.options, got, test.want)
+				break
+			}
+		}
+	}
+}
+
+func TestKeyphraseIndexAddRemove(t *testing.T) {
+	index := newTestKeyphraseIndex()
+	numPhrases := index.NumPhrases()
+
+	// adding a document again replaces its phrases
+	index.Add("gnn", ExtractDocument("Graph neural networks learn on graphs."), nil)
+	if index.NumDocuments() != 3 || index.NumPhrases() != numPhrases {
+		t.Errorf("got %d documents and %d phrases after adding a document again, want 3 and %d",
+			index.NumDocuments(), index.NumPhrases(), numPhrases)
+	}
+
+	index.Remove("gnn")
+	index.Remove("unknown")
+	if index.NumDocuments() != 2 {
+		t.Errorf("NumDocuments = %d after Remove, want 2", index.NumDocuments())
+	}
+	if got := searchDocuments(index, "graphs", SearchOptions{Includes: true}); len(got) != 0 {
+		t.Errorf("Search found %q after Remove", got)
+	}
+	if got := searchDocuments(index, "neural networks", SearchOptions{Includes: true}); len(got) != 1 || got[0] != "cnn" {
+		t.Errorf("Search(\"neural networks\") = %q after Remove, want [cnn]", got)
+	}
+}
+
+func TestKeyphraseIndexStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.log")
+	index, err := OpenKeyphraseIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	memory := newTestKeyphraseIndex()
+	for _, document := range []struct{ id, text string }{
+		{"cnn", "Convolutional neural networks (CNNs) classify images. CNNs are deep."},
+		{"sota", "State-of-the-art methods beat the baselines."},
+		{"gnn", "Graph neural networks learn on graphs."},
+		{"old", "Support vector machines."},
+	} {
+		if err := index.Add(document.id, acronymExtractor.ExtractDocument(document.text), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := index.Remove("old"); err != nil {
+		t.Fatal(err)
+	}
+	if err := index.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the reopened index has the same documents and finds the same ones, acronyms included
+	checkReopened := func(step string) *KeyphraseIndex {
+		reopened, err := OpenKeyphraseIndex(path)
+		if err != nil {
+			t.Fatalf("%s: %v", step, err)
+		}
+		if reopened.NumDocuments() != 3 || reopened.NumPhrases() != memory.NumPhrases() {
+			t.Fatalf("%s: reopened %d documents and %d phrases, want 3 and %d", step,
+				reopened.NumDocuments(), reopened.NumPhrases(), memory.NumPhrases())
+		}
+		for _, query := range []string{"CNNs", "state-of-the-art methods", "neural networks"} {
+			options := SearchOptions{Includes: true}
+			got, want := reopened.Search(query, options), memory.Search(query, options)
+			if len(got) != len(want) || len(got) == 0 || got[0].Document != want[0].Document ||
+				got[0].Score != want[0].Score {
+				t.Errorf("%s: Search(%q) = %+v, want %+v", step, query, got, want)
+			}
+		}
+		return reopened
+	}
+	reopened := checkReopened("reopen")
+
+	// a record cut short by a crash is dropped
+	if err := reopened.Add("partial", ExtractDocument("Random forests."), nil); err != nil {
+		t.Fatal(err)
+	}
+	reopened.Close()
+	info, _ := os.Stat(path)
+	if err := os.Truncate(path, info.Size()-3); err != nil {
+		t.Fatal(err)
+	}
+	reopened = checkReopened("torn record")
+
+	// the log is rewritten with one record per document
+	if err := reopened.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	if reopened.store.numRecords != 4 {
+		t.Errorf("%d records after Compact, want 4", reopened.store.numRecords)
+	}
+	if err := reopened.Close(); err != nil {
+		t.Fatal(err)
+	}
+	checkReopened("compact").Close()
+
+	if err := os.WriteFile(path, []byte("not an index"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenKeyphraseIndex(path); err == nil {
+		t.Error("OpenKeyphraseIndex opened a file that is not an index")
+	}
+}
+
+func TestKeyphraseIndexAutoCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.log")
+	index, err := OpenKeyphraseIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+	scores := map[string]float64{"graph": 1.0}
+	for i := 0; i < 1500; i++ {
+		if err := index.Add("doc", nil, scores); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if index.store.numRecords >= 1000 {
+		t.Errorf("%d records for a single document", index.store.numRecords)
+	}
+}
+
+func TestKeyphraseIndexWriteRead(t *testing.T) {
+	index := newTestKeyphraseIndex()
+	var buffer bytes.Buffer
+	if _, err := index.WriteTo(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	loaded := NewKeyphraseIndex()
+	if _, err := loaded.ReadFrom(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.NumDocuments() != index.NumDocuments() || loaded.NumPhrases() != index.NumPhrases() {
+		t.Errorf("read %d documents and %d phrases, want %d and %d",
+			loaded.NumDocuments(), loaded.NumPhrases(), index.NumDocuments(), index.NumPhrases())
+	}
+}
@@ -0,0 +1,349 @@
+package KeyphraseExtraction
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/bits"
+	"math/rand"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Shingling tells how a phrase is turned into a set for MinHash.
+type Shingling int
+
+const (
+	// ShingleWords takes the set of words of a phrase.
+	ShingleWords Shingling = iota
+
+	// ShingleCharacters takes the set of character trigrams of a phrase, padded with spaces.
+	ShingleCharacters
+)
+
+// LSHOptions controls the approximate nearest neighbors found by locality-sensitive hashing. A
+// pair of phrases is compared if their signatures agree on all the rows of at least one band: more
+// bands find more of the similar pairs (recall), more rows per band compare fewer dissimilar
+// pairs (time). The pairs compared are measured exactly, so that the neighbors found are exact.
+type LSHOptions struct {
+	// NumBands is the number of bands of the signatures. 0 means 16.
+	NumBands int
+
+	// RowsPerBand is the number of hash values in each band, at most 64. 0 means 4 for MinHash, and
+	// for random hyperplanes, whose rows are single bits, the number of bits of the number of
+	// phrases, at least 16, so that a bucket holds about one random phrase.
+	RowsPerBand int
+
+	// MaxBucketSize is the largest number of phrases of a bucket whose pairs are compared. The pairs
+	// of a larger bucket are only compared if they share a bucket of another band, so that a
+	// crowded bucket does not compare all its pairs. 0 means 1000.
+	MaxBucketSize int
+
+	// Threshold is the lowest similarity kept between two different phrases. 0 means 0.5.
+	Threshold float64
+
+	// TopK is the number of nearest neighbors of each phrase that are kept, as in
+	// EmbeddingSimilarityOptions. 0 means no limit.
+	TopK int
+
+	// Seed is the seed of the random hash functions.
+	Seed int64
+}
+
+// =================================================================================================
+// method LSHOptions.bands
+// brief description:
+//   Get the number of bands and the number of rows per band.
+// input:
+//   defaultRowsPerBand: the number of rows per band if RowsPerBand is 0
+// output:
+//   the numbers, with their defaults
+
+func (options LSHOptions) bands(defaultRowsPerBand int) (int, int) {
+	numBands, rowsPerBand := options.NumBands, options.RowsPerBand
+	if numBands <= 0 {
+		numBands = 16
+	}
+	if rowsPerBand <= 0 {
+		rowsPerBand = defaultRowsPerBand
+	}
+	if rowsPerBand > 64 {
+		rowsPerBand = 64
+	}
+	return numBands, rowsPerBand
+}
+
+// =================================================================================================
+// function mix64
+// brief description:
+//   Mix the bits of a 64-bit value (the finalizer of SplitMix64).
+// input:
+//   x: the value
+// output:
+//   the mixed value
+
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// =================================================================================================
+// function lshNeighbors
+// brief description:
+//   Find the neighbors of each phrase among the phrases that share a band of signature.
+// input:
+//   phrases: the phrases
+//   bandKeys: the key of each band of the signature of each phrase
+//   numBands: the number of bands
+//   similarity: the exact similarity between two phrases by their indices
+//   options: the options of LSH
+// output:
+//   the symmetric similarity matrix of the neighbors kept
+
+func lshNeighbors(phrases []string, bandKeys [][]uint64, numBands int, similarity func(i, j int) float64,
+	options LSHOptions) *SimilarityMatrix {
+	// --------------------------------------------------------------------------------------------
+	// step 1: put the phrases into the buckets of each band, without the crowded buckets
+	maxBucketSize := options.MaxBucketSize
+	if maxBucketSize <= 0 {
+		maxBucketSize = 1000
+	}
+	threshold := options.Threshold
+	if threshold == 0 {
+		threshold = 0.5
+	}
+	buckets := make([]map[uint64][]int, numBands)
+	for band := range buckets {
+		buckets[band] = map[uint64][]int{}
+		for i := range phrases {
+			key := bandKeys[i][band]
+			buckets[band][key] = append(buckets[band][key], i)
+		}
+		for key, bucket := range buckets[band] {
+			if len(bucket) > maxBucketSize {
+				delete(buckets[band], key)
+			}
+		}
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 2: measure the phrases that share a bucket, on all the CPUs
+	numPhrases := len(phrases)
+	rows := make([][]phraseNeighbor, numPhrases)
+	chI := make(chan int)
+	var wg sync.WaitGroup
+	for idxCPU := 0; idxCPU < runtime.NumCPU(); idxCPU++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range chI {
+				seen := map[int]bool{i: true}
+				neighbors := []phraseNeighbor{}
+				for band := range buckets {
+					for _, j := range buckets[band][bandKeys[i][band]] {
+						if seen[j] {
+							continue
+						}
+						seen[j] = true
+						if sim := similarity(i, j); sim >= threshold {
+							neighbors = append(neighbors, phraseNeighbor{j, sim})
+						}
+					}
+				}
+				rows[i] = neighbors
+			}
+		}()
+	}
+	for i := 0; i < numPhrases; i++ {
+		chI <- i
+	}
+	close(chI)
+	wg.Wait()
+
+	// --------------------------------------------------------------------------------------------
+	// step 3: keep the top neighbors in a symmetric matrix
+	result := NewSimilarityMatrix()
+	for i, neighbors := range rows {
+		for neighbor, sim := range similarityRow(phrases, i, neighbors, options.TopK) {
+			result.Set(phrases[i], neighbor, sim)
+		}
+	}
+	result.Compact()
+	return result
+}
+
+// =================================================================================================
+// function shingles
+// brief description:
+//   Get the set of shingles of a phrase, as hashes.
+// input:
+//   phrase: the phrase
+//   shingling: the kind of shingles
+// output:
+//   the set of hashes of the shingles
+
+func shingles(phrase string, shingling Shingling) map[uint64]bool {
+	texts := []string{}
+	if shingling == ShingleCharacters {
+		for trigram := range characterTrigrams(phrase) {
+			texts = append(texts, trigram)
+		}
+	} else {
+		texts = strings.Split(phrase, " ")
+	}
+	result := make(map[uint64]bool, len(texts))
+	for _, text := range texts {
+		hash := fnv.New64a()
+		hash.Write([]byte(text))
+		result[hash.Sum64()] = true
+	}
+	return result
+}
+
+// =================================================================================================
+// function MinHashSimilarity
+// brief description:
+//   Build a sparse similarity matrix between many phrases with MinHash-LSH, comparing only the
+//   phrases likely to be similar.
+// input:
+//   phrases: the phrases, e.g. the stemmed phrases of a vocabulary
+//   shingling: whether the phrases are compared by words or by character trigrams
+//   options: the options of LSH
+// output:
+//   the Jaccard similarity between the phrases and their neighbors found, which can be passed to
+//   SimTFWith and SimIDFWith
+// notes:
+//   A pair of similarity s is compared with a probability of 1 - (1 - s^r)^b, for b bands of r
+//   rows. With the default 16 bands of 4 rows, it is 1.0 for s = 0.8, 0.89 for 0.6, 0.64 for 0.5,
+//   0.12 for 0.3 and 0.002 for 0.1. The reference is:
+//   Leskovec, J., Rajaraman, A., & Ullman, J. D. (2014). Mining of massive datasets (Chapter 3).
+//   Cambridge University Press.
+
+func MinHashSimilarity(phrases []string, shingling Shingling, options LSHOptions) *SimilarityMatrix {
+	// --------------------------------------------------------------------------------------------
+	// step 1: compute the shingles and the signatures
+	numBands, rowsPerBand := options.bands(4)
+	random := rand.New(rand.NewSource(options.Seed))
+	seeds := make([]uint64, numBands*rowsPerBand)
+	for i := range seeds {
+		seeds[i] = random.Uint64()
+	}
+	sets := make([]map[uint64]bool, len(phrases))
+	bandKeys := make([][]uint64, len(phrases))
+	for i, phrase := range phrases {
+		sets[i] = shingles(phrase, shingling)
+		bandKeys[i] = make([]uint64, numBands)
+		for band := 0; band < numBands; band++ {
+			key := uint64(band)
+			for row := 0; row < rowsPerBand; row++ {
+				minimum := uint64(math.MaxUint64)
+				for shingle := range sets[i] {
+					if value := mix64(shingle ^ seeds[band*rowsPerBand+row]); value < minimum {
+						minimum = value
+					}
+				}
+				key = mix64(key ^ minimum)
+			}
+			bandKeys[i][band] = key
+		}
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 2: find the neighbors with the exact Jaccard similarity
+	jaccard := func(i, j int) float64 {
+		numShared := 0
+		for shingle := range sets[i] {
+			if sets[j][shingle] {
+				numShared++
+			}
+		}
+		union := len(sets[i]) + len(sets[j]) - numShared
+		if union == 0 {
+			return 0.0
+		}
+		return float64(numShared) / float64(union)
+	}
+	return lshNeighbors(phrases, bandKeys, numBands, jaccard, options)
+}
+
+// =================================================================================================
+// function HyperplaneSimilarity
+// brief description:
+//   Build a sparse similarity matrix between many phrase vectors with random-hyperplane LSH,
+//   comparing only the phrases likely to be similar.
+// input:
+//   phraseVectors: the vector of each phrase, e.g. from PhraseVectors
+//   options: the options of LSH
+// output:
+//   the cosine similarity between the phrases and their neighbors found, which can be passed to
+//   SimTFWith and SimIDFWith, and an error if the vectors do not have the same dimension
+// notes:
+//   A pair at an angle t is compared with a probability of 1 - (1 - (1 - t/pi)^r)^b, for b bands of
+//   r rows. With 16 bands of 16 rows, it is 0.96 for a cosine of 0.95, 0.75 for 0.9, 0.34 for
+//   0.8, 0.02 for 0.5 and 0.0002 for 0; with 20 rows, for a million phrases, it is 0.87 for 0.95,
+//   0.52 for 0.9 and 0.15 for 0.8. More bands find more of the neighbors. The reference is:
+//   Charikar, M. S. (2002). Similarity estimation techniques from rounding algorithms. In STOC
+//   (pp. 380-388).
+
+func HyperplaneSimilarity(phraseVectors map[string][]float64, options LSHOptions) (*SimilarityMatrix, error) {
+	// --------------------------------------------------------------------------------------------
+	// step 1: check the vectors and draw the hyperplanes
+	defaultRowsPerBand := bits.Len(uint(len(phraseVectors)))
+	if defaultRowsPerBand < 16 {
+		defaultRowsPerBand = 16
+	}
+	numBands, rowsPerBand := options.bands(defaultRowsPerBand)
+	phrases := make([]string, 0, len(phraseVectors))
+	dimension := -1
+	for phrase, vector := range phraseVectors {
+		if dimension >= 0 && len(vector) != dimension {
+			return nil, fmt.Errorf("KeyphraseExtraction: vector of %q has dimension %d instead of %d", phrase,
+				len(vector), dimension)
+		}
+		phrases = append(phrases, phrase)
+		dimension = len(vector)
+	}
+	if dimension < 0 {
+		dimension = 0
+	}
+	random := rand.New(rand.NewSource(options.Seed))
+	hyperplanes := make([][]float64, numBands*rowsPerBand)
+	for i := range hyperplanes {
+		hyperplanes[i] = make([]float64, dimension)
+		for d := range hyperplanes[i] {
+			hyperplanes[i][d] = random.NormFloat64()
+		}
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 2: compute the signatures, one bit per hyperplane
+	bandKeys := make([][]uint64, len(phrases))
+	for i, phrase := range phrases {
+		vector := phraseVectors[phrase]
+		bandKeys[i] = make([]uint64, numBands)
+		for band := 0; band < numBands; band++ {
+			bits := uint64(0)
+			for row := 0; row < rowsPerBand; row++ {
+				dot := 0.0
+				for d, value := range hyperplanes[band*rowsPerBand+row] {
+					dot += value * vector[d]
+				}
+				if dot >= 0 {
+					bits |= 1 << uint(row)
+				}
+			}
+			bandKeys[i][band] = mix64(uint64(band)) ^ bits
+		}
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 3: find the neighbors with the exact cosine similarity
+	return lshNeighbors(phrases, bandKeys, numBands, func(i, j int) float64 {
+		return cosine(phraseVectors[phrases[i]], phraseVectors[phrases[j]])
+	}, options), nil
+}
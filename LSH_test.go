@@ -0,0 +1,131 @@
+package KeyphraseExtraction
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestMinHashSimilarity(t *testing.T) {
+	phrases := []string{
+		"deep neural network model", "deep neural network", "neural network",
+		"graph theory", "graph theory algorithm", "language model",
+	}
+	matrix := MinHashSimilarity(phrases, ShingleWords, LSHOptions{NumBands: 64, Seed: 1})
+	for _, test := range []struct {
+		phrase1, phrase2 string
+		want             float64
+	}{
+		{"deep neural network model", "deep neural network", 0.75},
+		{"deep neural network", "neural network", 2.0 / 3.0},
+		{"graph theory", "graph theory algorithm", 2.0 / 3.0},
+		{"graph theory", "neural network", 0},
+		{"deep neural network model", "language model", 0},
+	} {
+		if got := matrix.Similarity(test.phrase1, test.phrase2); math.Abs(got-test.want) > 1e-6 {
+			t.Errorf("Similarity(%q, %q) = %v, want %v", test.phrase1, test.phrase2, got, test.want)
+		}
+	}
+
+	// character trigrams find the variants of a word
+	matrix = MinHashSimilarity([]string{"network", "networks", "graph"}, ShingleCharacters, LSHOptions{Threshold: 0.3})
+	if matrix.Similarity("network", "networks") < 0.5 || matrix.Similarity("network", "graph") != 0 {
+		t.Errorf("trigram similarities %v and %v", matrix.Similarity("network", "networks"),
+			matrix.Similarity("network", "graph"))
+	}
+}
+
+// plantedVectors draws random vectors, each with a perturbed copy of cosine about 0.97.
+func plantedVectors(numPairs, dimension int) map[string][]float64 {
+	random := rand.New(rand.NewSource(7))
+	vectors := map[string][]float64{}
+	for i := 0; i < numPairs; i++ {
+		vector, copy := make([]float64, dimension), make([]float64, dimension)
+		for d := range vector {
+			vector[d] = random.NormFloat64()
+			copy[d] = vector[d] + 0.25*random.NormFloat64()
+		}
+		vectors[fmt.Sprintf("p%d", i)] = vector
+		vectors[fmt.Sprintf("p%d copy", i)] = copy
+	}
+	return vectors
+}
+
+func TestHyperplaneSimilarityRecall(t *testing.T) {
+	vectors := plantedVectors(200, 32)
+	matrix, err := HyperplaneSimilarity(vectors, LSHOptions{Seed: 1, Threshold: 0.8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := 0
+	for i := 0; i < 200; i++ {
+		if matrix.Similarity(fmt.Sprintf("p%d", i), fmt.Sprintf("p%d copy", i)) > 0 {
+			found++
+		}
+	}
+	if found < 180 {
+		t.Errorf("found %d of the 200 planted pairs", found)
+	}
+	// random vectors of dimension 32 have a cosine above 0.8 with a negligible probability
+	if numPairs := matrix.NumPairs(); numPairs > found {
+		t.Errorf("got %d pairs for %d planted pairs found", numPairs, found)
+	}
+}
+
+func TestHyperplaneSimilarityTopK(t *testing.T) {
+	vectors := plantedVectors(50, 8)
+	matrix, err := HyperplaneSimilarity(vectors, LSHOptions{NumBands: 64, RowsPerBand: 4, Threshold: -1, TopK: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a pair is kept only if one phrase is the nearest neighbor of the other
+	nearest := map[string]string{}
+	for phrase1, vector1 := range vectors {
+		best := math.Inf(-1)
+		for phrase2, vector2 := range vectors {
+			if sim := cosine(vector1, vector2); phrase2 != phrase1 && sim > best {
+				nearest[phrase1], best = phrase2, sim
+			}
+		}
+	}
+	numKept := 0
+	for phrase1 := range vectors {
+		matrix.ForEachNeighbor(phrase1, func(phrase2 string, sim float64) bool {
+			if phrase2 != phrase1 {
+				numKept++
+				if nearest[phrase1] != phrase2 && nearest[phrase2] != phrase1 {
+					t.Errorf("%q and %q are not nearest neighbors", phrase1, phrase2)
+				}
+			}
+			return true
+		})
+	}
+	if numKept == 0 {
+		t.Error("no neighbors kept")
+	}
+}
+
+func TestLSHMaxBucketSize(t *testing.T) {
+	vectors := map[string][]float64{}
+	for i := 0; i < 50; i++ {
+		vectors[fmt.Sprintf("p%d", i)] = []float64{1, 2, 3}
+	}
+	for _, test := range []struct{ maxBucketSize, want int }{{0, 50 * 49 / 2}, {10, 0}} {
+		matrix, err := HyperplaneSimilarity(vectors, LSHOptions{MaxBucketSize: test.maxBucketSize})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if matrix.NumPairs() != test.want {
+			t.Errorf("MaxBucketSize %d: got %d pairs, want %d", test.maxBucketSize, matrix.NumPairs(), test.want)
+		}
+	}
+}
+
+func TestHyperplaneSimilarityDimensions(t *testing.T) {
+	vectors := map[string][]float64{"a": {1, 2, 3}, "b": {1, 2}}
+	if _, err := HyperplaneSimilarity(vectors, LSHOptions{}); err == nil {
+		t.Error("HyperplaneSimilarity accepted vectors of different dimensions")
+	}
+}
@@ -0,0 +1,187 @@
+package KeyphraseExtraction
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// PhraseSimilarity gives the similarity between two phrases, 1 for identical phrases.
+type PhraseSimilarity func(phrase1, phrase2 string) float64
+
+// =================================================================================================
+// function MatrixSimilarity
+// brief description:
+//   Get the similarity given by a sparse similarity matrix, such as the one of SimTF and SimIDF.
+// input:
+//   phraseSimilarity: a sparse matrix that gives similarity between strings
+// output:
+//   the similarity, which is 1 for identical phrases, the larger of the two directions if the
+//   matrix is not symmetric, and 0 for the pairs not in the matrix
+
+func MatrixSimilarity(phraseSimilarity map[string]map[string]float64) PhraseSimilarity {
+	return func(phrase1, phrase2 string) float64 {
+		if phrase1 == phrase2 {
+			return 1.0
+		}
+		return math.Max(phraseSimilarity[phrase1][phrase2], phraseSimilarity[phrase2][phrase1])
+	}
+}
+
+// =================================================================================================
+// function characterTrigrams
+// brief description:
+//   Get the set of character trigrams of a phrase, padded with spaces.
+// input:
+//   phrase: the phrase
+// output:
+//   the set of trigrams
+
+func characterTrigrams(phrase string) map[string]bool {
+	runes := []rune(" " + phrase + " ")
+	result := map[string]bool{}
+	for i := 0; i+3 <= len(runes); i++ {
+		result[string(runes[i:i+3])] = true
+	}
+	return result
+}
+
+// =================================================================================================
+// function StringSimilarity
+// brief description:
+//   Compute a built-in similarity between two phrases from their words and characters.
+// input:
+//   phrase1, phrase2: the phrases
+// output:
+//   the larger of the Jaccard similarity of their sets of words and the Dice similarity of their
+//   sets of character trigrams, so that "neural network" is similar both to "deep neural network"
+//   and to "neural networks"
+
+func StringSimilarity(phrase1, phrase2 string) float64 {
+	// --------------------------------------------------------------------------------------------
+	// step 1: identical phrases
+	if phrase1 == phrase2 {
+		return 1.0
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 2: Jaccard similarity of words
+	words1 := map[string]bool{}
+	for _, word := range strings.Split(phrase1, " ") {
+		words1[word] = true
+	}
+	words2 := map[string]bool{}
+	for _, word := range strings.Split(phrase2, " ") {
+		words2[word] = true
+	}
+	numShared := 0
+	for word := range words2 {
+		if words1[word] {
+			numShared++
+		}
+	}
+	wordSimilarity := float64(numShared) / float64(len(words1)+len(words2)-numShared)
+
+	// --------------------------------------------------------------------------------------------
+	// step 3: Dice similarity of character trigrams
+	trigrams1 := characterTrigrams(phrase1)
+	trigrams2 := characterTrigrams(phrase2)
+	numShared = 0
+	for trigram := range trigrams2 {
+		if trigrams1[trigram] {
+			numShared++
+		}
+	}
+	trigramSimilarity := 2.0 * float64(numShared) / float64(len(trigrams1)+len(trigrams2))
+
+	return math.Max(wordSimilarity, trigramSimilarity)
+}
+
+// =================================================================================================
+// function MMR
+// brief description:
+//   Select diverse key phrases with Maximal Marginal Relevance, which balances the score of each
+//   phrase against its largest similarity to the phrases already selected.
+// input:
+//   scores: the score of each phrase, e.g. its TF-IDF
+//   k: the number of phrases to select, or 0 for all of them
+//   lambda: the balance between relevance (1) and diversity (0)
+//   similarity: the similarity between phrases, or nil for StringSimilarity
+// output:
+//   the selected phrases, in the order of selection, without the phrases whose scores are not
+//   finite. A similarity that is not a number counts as 0, and an infinite one leaves its phrases
+//   to be selected last, so that k phrases are selected if there are enough finite scores.
+// notes:
+//   The scores are rescaled to [0, 1] so that they are comparable with the similarities. Each step
+//   selects the phrase p maximizing
+//     lambda * score(p) - (1 - lambda) * max(similarity(p, q) for q selected).
+//   The reference is:
+//   Carbonell, J., & Goldstein, J. (1998). The use of MMR, diversity-based reranking for
+//   reordering documents and producing summaries. In SIGIR (pp. 335-336).
+
+func MMR(scores map[string]float64, k int, lambda float64, similarity PhraseSimilarity) []string {
+	// --------------------------------------------------------------------------------------------
+	// step 1: sort the phrases and rescale the scores
+	if similarity == nil {
+		similarity = StringSimilarity
+	}
+	phrases := make([]string, 0, len(scores))
+	for phrase, score := range scores {
+		if !math.IsNaN(score) && !math.IsInf(score, 0) {
+			phrases = append(phrases, phrase)
+		}
+	}
+	sort.Slice(phrases, func(i, j int) bool {
+		if scores[phrases[i]] != scores[phrases[j]] {
+			return scores[phrases[i]] > scores[phrases[j]]
+		}
+		return phrases[i] < phrases[j]
+	})
+	numPhrases := len(phrases)
+	if k <= 0 || k > numPhrases {
+		k = numPhrases
+	}
+	if numPhrases == 0 {
+		return []string{}
+	}
+	maxScore := scores[phrases[0]]
+	minScore := scores[phrases[numPhrases-1]]
+	relevance := make([]float64, numPhrases)
+	for i, phrase := range phrases {
+		relevance[i] = 1.0
+		if maxScore > minScore {
+			relevance[i] = (scores[phrase] - minScore) / (maxScore - minScore)
+		}
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 2: select the phrases one by one, keeping the largest similarity of each phrase to
+	//         the selected ones
+	result := make([]string, 0, k)
+	selected := make([]bool, numPhrases)
+	maxSimilarity := make([]float64, numPhrases)
+	for len(result) < k {
+		best := -1
+		bestValue := math.Inf(-1)
+		for i := range phrases {
+			if selected[i] {
+				continue
+			}
+			value := lambda*relevance[i] - (1.0-lambda)*maxSimilarity[i]
+			if best < 0 || value > bestValue {
+				best = i
+				bestValue = value
+			}
+		}
+		selected[best] = true
+		result = append(result, phrases[best])
+		for i := range phrases {
+			if !selected[i] {
+				if sim := similarity(phrases[i], phrases[best]); !math.IsNaN(sim) {
+					maxSimilarity[i] = math.Max(maxSimilarity[i], sim)
+				}
+			}
+		}
+	}
+	return result
+}
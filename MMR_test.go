@@ -0,0 +1,35 @@
+package KeyphraseExtraction
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestMMRNonFinite(t *testing.T) {
+	scores := map[string]float64{
+		"neural network": 3,
+		"graph":          math.NaN(),
+		"deep learning":  math.Inf(1),
+		"language model": 1,
+	}
+	if got := MMR(scores, 0, 0.7, nil); len(got) != 2 || got[0] != "neural network" {
+		t.Errorf("MMR = %q, want the 2 finite phrases", got)
+	}
+
+	// a similarity that is not a number counts as 0, and an infinite one ranks its phrases last
+	scores = map[string]float64{"a": 3, "b": 2, "c": 1, "d": 0}
+	nan := func(string, string) float64 { return math.NaN() }
+	if got := MMR(scores, 3, 0.5, nan); fmt.Sprint(got) != "[a b c]" {
+		t.Errorf("MMR with NaN similarities = %q, want [a b c]", got)
+	}
+	infinite := func(phrase1, phrase2 string) float64 {
+		if phrase1 == "b" || phrase2 == "b" {
+			return math.Inf(1)
+		}
+		return 0
+	}
+	if got := MMR(scores, 0, 0.5, infinite); fmt.Sprint(got) != "[a c d b]" {
+		t.Errorf("MMR with infinite similarities = %q, want [a c d b]", got)
+	}
+}
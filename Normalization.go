@@ -0,0 +1,156 @@
+package KeyphraseExtraction
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+var apostrophes map[rune]bool
+var greekLetterNames map[rune]string
+
+func init() {
+	apostrophes = make(map[rune]bool)
+	apostrophes['’'] = true
+	apostrophes['‘'] = true
+	apostrophes['ʼ'] = true
+	apostrophes['′'] = true
+	apostrophes['`'] = true
+
+	greekLetterNames = make(map[rune]string)
+	for i, name := range []string{
+		"alpha", "beta", "gamma", "delta", "epsilon", "zeta", "eta", "theta", "iota", "kappa",
+		"lambda", "mu", "nu", "xi", "omicron", "pi", "rho", "", "sigma", "tau", "upsilon", "phi",
+		"chi", "psi", "omega",
+	} {
+		if len(name) == 0 {
+			continue
+		}
+		greekLetterNames['Α'+rune(i)] = name
+		greekLetterNames['α'+rune(i)] = name
+	}
+	greekLetterNames['ς'] = "sigma"
+	greekLetterNames['ϕ'] = "phi"
+	greekLetterNames['ϵ'] = "epsilon"
+	greekLetterNames['ϑ'] = "theta"
+}
+
+// =================================================================================================
+// function foldDiacritics
+// brief description:
+//   Remove the diacritics from the letters of the input text, e.g. "naïve" to "naive".
+// input:
+//   text: The input text.
+// output:
+//   The text without diacritics.
+
+func foldDiacritics(text string) string {
+	decomposed := norm.NFD.String(text)
+	var builder strings.Builder
+	builder.Grow(len(decomposed))
+	for _, r := range decomposed {
+		if !unicode.Is(unicode.Mn, r) {
+			builder.WriteRune(r)
+		}
+	}
+	return norm.NFC.String(builder.String())
+}
+
+// offsetRune is a rune of a normalized text, with the byte offset in the original text of the
+// chars it comes from.
+type offsetRune struct {
+	r      rune
+	offset int
+}
+
+// =================================================================================================
+// function spellGreekLetters
+// brief description:
+//   Spell out the Greek letters that stand alone or are attached to non-letters, e.g. "α-helix" to
+//   "alpha-helix" and "TNF-α" to "TNF-alpha". Greek letters within words, such as the "μ" of "μs",
+//   are kept.
+// input:
+//   runes: The runes of the input text.
+// output:
+//   The runes with the Greek letters spelled out.
+
+func spellGreekLetters(runes []offsetRune) []offsetRune {
+	numRunes := len(runes)
+	result := make([]offsetRune, 0, numRunes)
+	for i, or := range runes {
+		name, isGreekLetter := greekLetterNames[or.r]
+		if isGreekLetter &&
+			(i == 0 || !unicode.IsLetter(runes[i-1].r)) &&
+			(i+1 == numRunes || !unicode.IsLetter(runes[i+1].r)) {
+			for _, r := range name {
+				result = append(result, offsetRune{r: r, offset: or.offset})
+			}
+		} else {
+			result = append(result, or)
+		}
+	}
+	return result
+}
+
+// =================================================================================================
+// function normalizeText
+// brief description:
+//   Normalize the Unicode text before tokenization, so that different encodings of the same phrase
+//   produce the same candidate.
+// input:
+//   text: The input text.
+//   options: The options of the pipeline.
+// output:
+//   The text that has been:
+//   (1) normalized with NFKC, which converts full-width letters and ligatures such as "ﬁ",
+//   (2) folded to remove diacritics if options.FoldDiacritics is set,
+//   (3) rewritten with straight apostrophes,
+//   (4) rewritten with Greek letters spelled out unless options.KeepGreekLetters is set,
+//   (5) rewritten with normalized hyphens and dashes.
+//   And for each byte of the normalized text (and its end), the byte offset in the original text.
+
+func normalizeText(text string, options Options) (string, []int) {
+	// --------------------------------------------------------------------------------------------
+	// step 1: NFKC, diacritics and apostrophes, segment by segment to keep track of the offsets
+	runes := make([]offsetRune, 0, len(text))
+	for begin := 0; begin < len(text); {
+		size := norm.NFKC.NextBoundaryInString(text[begin:], true)
+		if size <= 0 {
+			size = len(text) - begin
+		}
+		segment := norm.NFKC.String(text[begin : begin+size])
+		if options.FoldDiacritics {
+			segment = foldDiacritics(segment)
+		}
+		for _, r := range segment {
+			if apostrophes[r] {
+				r = '\''
+			}
+			runes = append(runes, offsetRune{r: r, offset: begin})
+		}
+		begin += size
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 2: Greek letters, hyphens and dashes
+	if !options.KeepGreekLetters {
+		runes = spellGreekLetters(runes)
+	}
+	runes = normalizeDashes(runes)
+
+	// --------------------------------------------------------------------------------------------
+	// step 3: Assemble the normalized text and its offsets
+	var builder strings.Builder
+	builder.Grow(len(runes))
+	offsets := make([]int, 0, len(runes)+1)
+	for _, or := range runes {
+		numBytes := builder.Len()
+		builder.WriteRune(or.r)
+		for i := numBytes; i < builder.Len(); i++ {
+			offsets = append(offsets, or.offset)
+		}
+	}
+	offsets = append(offsets, len(text))
+	return builder.String(), offsets
+}
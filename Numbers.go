@@ -0,0 +1,270 @@
+package KeyphraseExtraction
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var reDecimal *regexp.Regexp
+var reThousands *regexp.Regexp
+var reOrdinal *regexp.Regexp
+var reScaledNumber *regexp.Regexp
+var reDimension *regexp.Regexp
+var reMultiple *regexp.Regexp
+var reNumberWithUnit *regexp.Regexp
+var numberWords map[string]string
+var numberScales map[string]float64
+var units map[string]string
+
+func init() {
+	reDecimal = regexp.MustCompile(`^[0-9]+\.[0-9]+$`)
+	reThousands = regexp.MustCompile(`^[0-9]{1,3}(,[0-9]{3})+(\.[0-9]+)?$`)
+	reOrdinal = regexp.MustCompile(`^([0-9]+)(st|nd|rd|th|ST|ND|RD|TH)$`)
+	reScaledNumber = regexp.MustCompile(`^([0-9]+(\.[0-9]+)?)(k|K|M|bn)$`)
+	reDimension = regexp.MustCompile(`^([0-9]+|[a-zA-Z]+)(-?)([dD]|[dD]imensional)$`)
+	reMultiple = regexp.MustCompile(`^([0-9]+|[a-zA-Z]+)-?fold$`)
+	reNumberWithUnit = regexp.MustCompile(`^([0-9]+(\.[0-9]+)?)([a-zA-Zµμ°%]+)$`)
+
+	// numberWords: the numbers spelled in one word, from zero to twenty and the tens to ninety
+	numberWords = make(map[string]string)
+	for i, word := range []string{
+		"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine", "ten",
+		"eleven", "twelve", "thirteen", "fourteen", "fifteen", "sixteen", "seventeen", "eighteen",
+		"nineteen", "twenty",
+	} {
+		numberWords[word] = strconv.Itoa(i)
+	}
+	for i, word := range []string{"thirty", "forty", "fifty", "sixty", "seventy", "eighty", "ninety"} {
+		numberWords[word] = strconv.Itoa(30 + 10*i)
+	}
+
+	numberScales = make(map[string]float64)
+	numberScales["k"] = 1e3
+	numberScales["K"] = 1e3
+	numberScales["M"] = 1e6
+	// a bare "B" is bytes, as in "10B", so billions must be written "bn"
+	numberScales["bn"] = 1e9
+
+	// units: the spellings recognized after a number, mapped to their canonical spellings
+	units = make(map[string]string)
+	for _, unit := range []string{
+		"ns", "ms", "s", "min", "h",
+		"Hz", "kHz", "MHz", "GHz",
+		"B", "KB", "kB", "MB", "GB", "TB", "bps", "kbps", "Mbps", "Gbps",
+		"nm", "mm", "cm", "m", "km",
+		"mg", "g", "kg",
+		"W", "kW", "MW", "V", "mV", "kV", "mA",
+		"°C", "°F", "%", "px", "dpi", "fps",
+	} {
+		units[unit] = unit
+	}
+	units["sec"] = "s"
+	units["hr"] = "h"
+	units["hz"] = "Hz"
+	units["khz"] = "kHz"
+	units["mhz"] = "MHz"
+	units["ghz"] = "GHz"
+	units["GHZ"] = "GHz"
+	units["MHZ"] = "MHz"
+	units["us"] = "µs"
+	units["µs"] = "µs"
+	units["μs"] = "µs"
+	units["µm"] = "µm"
+	units["μm"] = "µm"
+}
+
+// =================================================================================================
+// function formatNumber
+// brief description:
+//   Format a number in its shortest decimal form, e.g. 1000 as "1000" and 3.50 as "3.5".
+// input:
+//   value: The number.
+// output:
+//   The formatted number.
+
+func formatNumber(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// =================================================================================================
+// function normalizeNumber
+// brief description:
+//   Normalize a single token that is a number.
+// input:
+//   token: The input token.
+// output:
+//   The normalized words of the token, or nil if the token is not a number:
+//   (1) "3D", "3-d" and "three-dimensional" become "3d", and "10fold" and "ten-fold" become
+//       "10-fold", for the numbers spelled in one word from zero to twenty and the tens to ninety,
+//   (2) "2nd" becomes "2",
+//   (3) "1,000" becomes "1000" and "3.50" becomes "3.5",
+//   (4) "10k" becomes "10000", "5M" becomes "5000000" and "2bn" becomes "2000000000",
+//   (5) "5GHz" becomes "5" "GHz".
+
+func normalizeNumber(token string) []string {
+	// --------------------------------------------------------------------------------------------
+	// step 1: dimensions and multiples
+	if match := reDimension.FindStringSubmatch(token); match != nil {
+		number := match[1]
+		word, isNumberWord := numberWords[strings.ToLower(number)]
+		// a spelled number needs a hyphen before a bare "d", so that "tend" is not "10d"
+		if isNumberWord && (match[2] != "" || len(match[3]) > 1) {
+			number = word
+		}
+		if reNumber.MatchString(number) {
+			return []string{number + "d"}
+		}
+	}
+	if match := reMultiple.FindStringSubmatch(token); match != nil {
+		number := match[1]
+		if word, isNumberWord := numberWords[strings.ToLower(number)]; isNumberWord {
+			number = word
+		}
+		if reNumber.MatchString(number) {
+			return []string{number + "-fold"}
+		}
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 2: ordinals
+	if match := reOrdinal.FindStringSubmatch(token); match != nil {
+		return []string{match[1]}
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 3: thousands separators and decimals
+	if reThousands.MatchString(token) || reDecimal.MatchString(token) {
+		value, err := strconv.ParseFloat(strings.ReplaceAll(token, ",", ""), 64)
+		if err == nil {
+			return []string{formatNumber(value)}
+		}
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 4: scale suffixes
+	if match := reScaledNumber.FindStringSubmatch(token); match != nil {
+		value, err := strconv.ParseFloat(match[1], 64)
+		if err == nil {
+			return []string{formatNumber(value * numberScales[match[3]])}
+		}
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 5: numbers glued with units
+	if match := reNumberWithUnit.FindStringSubmatch(token); match != nil {
+		// "1990s" is a decade rather than seconds
+		unit, isUnit := units[match[3]]
+		if isUnit && unit != "s" {
+			number := match[1]
+			if reDecimal.MatchString(number) {
+				value, _ := strconv.ParseFloat(number, 64)
+				number = formatNumber(value)
+			}
+			return []string{number, unit}
+		}
+	}
+	return nil
+}
+
+// =================================================================================================
+// function normalizeNumbers
+// brief description:
+//   Normalize the numbers, dimensions and units in a sequence of tokens.
+// input:
+//   tokens: The tokens of a text, puntuations included.
+// output:
+//   The converted tokens, and the conversions that have been made.
+
+func normalizeNumbers(tokens []token) ([]token, []Conversion) {
+	result := make([]token, 0, len(tokens))
+	conversions := []Conversion{}
+	for _, tok := range tokens {
+		numResults := len(result)
+		prevText := ""
+		if numResults > 0 {
+			prevText = result[numResults-1].text
+		}
+
+		// a number followed by a separate "D" or a separate unit, e.g. "3 D" or "10 GHZ"
+		if reNumber.MatchString(prevText) {
+			if tok.text == "D" || tok.text == "d" {
+				result[numResults-1].text = prevText + "d"
+				result[numResults-1].end = tok.end
+				conversions = append(conversions, Conversion{
+					Kind:      ConversionNumber,
+					Original:  prevText + " " + tok.text,
+					Converted: prevText + "d",
+					Begin:     result[numResults-1].begin,
+					End:       tok.end,
+				})
+				continue
+			}
+			// a separate "us" is the pronoun, as in "give 2 us", it is microseconds only in "5us"
+			unit, isUnit := units[tok.text]
+			if isUnit && unit != tok.text && tok.text != "us" {
+				conversions = append(conversions, Conversion{
+					Kind:      ConversionNumber,
+					Original:  tok.text,
+					Converted: unit,
+					Begin:     tok.begin,
+					End:       tok.end,
+				})
+				tok.text = unit
+				result = append(result, tok)
+				continue
+			}
+		}
+
+		// a single token that is a number
+		words := normalizeNumber(tok.text)
+		if len(words) == 0 || len(words) == 1 && words[0] == tok.text {
+			result = append(result, tok)
+			continue
+		}
+		conversions = append(conversions, Conversion{
+			Kind:      ConversionNumber,
+			Original:  tok.text,
+			Converted: strings.Join(words, " "),
+			Begin:     tok.begin,
+			End:       tok.end,
+		})
+		for _, word := range words {
+			converted := tok
+			converted.text = word
+			result = append(result, converted)
+		}
+	}
+	return result, conversions
+}
+
+// =================================================================================================
+// function isNumericWord
+// brief description:
+//   Check whether a word is an integer or a decimal number.
+// input:
+//   word: The input word.
+// output:
+//   true if the word is a number, false otherwise.
+
+func isNumericWord(word string) bool {
+	return reNumber.MatchString(word) || reDecimal.MatchString(word)
+}
+
+// =================================================================================================
+// function isNumericPhrase
+// brief description:
+//   Check whether a candidate phrase is made of numbers only.
+// input:
+//   words: The words of the candidate phrase.
+// output:
+//   true if all the words are numbers, false otherwise.
+
+func isNumericPhrase(words []string) bool {
+	for _, word := range words {
+		if !isNumericWord(word) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,32 @@
+package KeyphraseExtraction
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeNumbers(t *testing.T) {
+	for _, test := range []struct {
+		text, want string
+	}{
+		{"a 10B file", "a 10 B file"},
+		{"2bn users", "2000000000 users"},
+		{"5M users", "5000000 users"},
+		{"a 5us delay", "a 5 µs delay"},
+		{"a 5 us delay", "a 5 us delay"},
+		{"give 2 us", "give 2 us"},
+		{"10 GHZ", "10 GHz"},
+		{"a five-dimensional space", "a 5d space"},
+		{"Twenty-D and zero-d", "20d and 0d"},
+		{"ninety-dimensional", "90d"},
+		{"a ten-fold gain", "a 10-fold gain"},
+		{"tenfold 10-fold 10fold", "10-fold 10-fold 10-fold"},
+		{"they tend to a manifold", "they tend to a manifold"},
+		{"twenty-one-dimensional", "twenty-one-dimensional"},
+	} {
+		tokens, _ := normalizeNumbers(tokenizeText(test.text, false))
+		if got := strings.Join(tokenTexts(tokens), " "); got != test.want {
+			t.Errorf("normalizeNumbers(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
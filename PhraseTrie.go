@@ -0,0 +1,458 @@
+package KeyphraseExtraction
+
+import (
+	"math"
+	"strings"
+)
+
+// unknownWordID stands for the words that are not in the vocabulary of a PhraseTrie.
+const unknownWordID = WordID(math.MaxUint32)
+
+// trieNode is a node of a PhraseTrie, which stands for the phrase on the path from the root to it.
+type trieNode struct {
+	children map[WordID]int32
+	parent   int32
+	word     WordID
+	depth    int
+
+	// terminal tells whether the phrase of the node has been inserted.
+	terminal          bool
+	count             uint
+	documentFrequency uint
+	lastDocument      int
+
+	// fail is the node of the longest proper suffix of the phrase that is also in the trie, and
+	// output is the nearest terminal node along the fail links.
+	fail   int32
+	output int32
+}
+
+// PhraseTrie is a word-level trie of key phrase candidates, with an Aho-Corasick automaton on it, so
+// that all the occurrences of the candidates in a token stream are counted in one pass. It is not
+// safe for concurrent use.
+type PhraseTrie struct {
+	vocabulary   *Vocabulary
+	nodes        []trieNode
+	numDocuments int
+	built        bool
+
+	// phrasesOfWord holds, for each word, the terminal nodes of the phrases that have the word.
+	phrasesOfWord map[WordID][]int32
+}
+
+// =================================================================================================
+// function NewPhraseTrie
+// brief description:
+//   Create an empty phrase trie.
+// input:
+//   vocabulary: The vocabulary that encodes the phrases, or nil to create a new one.
+// output:
+//   The new trie.
+
+func NewPhraseTrie(vocabulary *Vocabulary) *PhraseTrie {
+	if vocabulary == nil {
+		vocabulary = NewVocabulary()
+	}
+	return &PhraseTrie{
+		vocabulary:    vocabulary,
+		nodes:         []trieNode{{children: map[WordID]int32{}, lastDocument: -1}},
+		phrasesOfWord: map[WordID][]int32{},
+	}
+}
+
+// =================================================================================================
+// method PhraseTrie.Vocabulary
+// brief description:
+//   Get the vocabulary of the trie.
+// output:
+//   The vocabulary that encodes the phrases.
+
+func (trie *PhraseTrie) Vocabulary() *Vocabulary {
+	return trie.vocabulary
+}
+
+// =================================================================================================
+// method PhraseTrie.lookup
+// brief description:
+//   Convert a phrase to word IDs without adding words to the vocabulary.
+// input:
+//   phrase: The phrase.
+// output:
+//   The IDs of the words, where the unknown words are unknownWordID.
+
+func (trie *PhraseTrie) lookup(phrase string) []WordID {
+	words := strings.Split(phrase, " ")
+	result := make([]WordID, len(words))
+	for i, word := range words {
+		id, exists := trie.vocabulary.Lookup(word)
+		if !exists {
+			id = unknownWordID
+		}
+		result[i] = id
+	}
+	return result
+}
+
+// =================================================================================================
+// method PhraseTrie.find
+// brief description:
+//   Find the node of a phrase.
+// input:
+//   ids: The word IDs of the phrase.
+// output:
+//   The index of the node, or -1 if the phrase is not a prefix of any inserted phrase.
+
+func (trie *PhraseTrie) find(ids []WordID) int32 {
+	node := int32(0)
+	for _, id := range ids {
+		child, exists := trie.nodes[node].children[id]
+		if !exists {
+			return -1
+		}
+		node = child
+	}
+	return node
+}
+
+// =================================================================================================
+// method PhraseTrie.phraseOf
+// brief description:
+//   Get the phrase of a node.
+// input:
+//   node: The index of the node.
+// output:
+//   The phrase on the path from the root to the node.
+
+func (trie *PhraseTrie) phraseOf(node int32) string {
+	ids := make([]WordID, trie.nodes[node].depth)
+	for ; node != 0; node = trie.nodes[node].parent {
+		ids[trie.nodes[node].depth-1] = trie.nodes[node].word
+	}
+	return trie.vocabulary.Decode(ids)
+}
+
+// =================================================================================================
+// method PhraseTrie.InsertIDs
+// brief description:
+//   Insert a phrase into the trie.
+// input:
+//   ids: The word IDs of the phrase.
+
+func (trie *PhraseTrie) InsertIDs(ids []WordID) {
+	if len(ids) == 0 {
+		return
+	}
+	node := int32(0)
+	for _, id := range ids {
+		child, exists := trie.nodes[node].children[id]
+		if !exists {
+			child = int32(len(trie.nodes))
+			trie.nodes = append(trie.nodes, trieNode{
+				children:     map[WordID]int32{},
+				parent:       node,
+				word:         id,
+				depth:        trie.nodes[node].depth + 1,
+				lastDocument: -1,
+			})
+			trie.nodes[node].children[id] = child
+			trie.built = false
+		}
+		node = child
+	}
+	if !trie.nodes[node].terminal {
+		trie.nodes[node].terminal = true
+		trie.built = false
+		seen := map[WordID]bool{}
+		for _, id := range ids {
+			if !seen[id] {
+				seen[id] = true
+				trie.phrasesOfWord[id] = append(trie.phrasesOfWord[id], node)
+			}
+		}
+	}
+}
+
+// =================================================================================================
+// method PhraseTrie.Insert
+// brief description:
+//   Insert a phrase into the trie.
+// input:
+//   phrase: The phrase, whose words are separated by single spaces.
+
+func (trie *PhraseTrie) Insert(phrase string) {
+	trie.InsertIDs(trie.vocabulary.Encode(phrase))
+}
+
+// =================================================================================================
+// method PhraseTrie.build
+// brief description:
+//   Build the fail and output links of the Aho-Corasick automaton, if the trie has changed.
+
+func (trie *PhraseTrie) build() {
+	if trie.built {
+		return
+	}
+	queue := []int32{0}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for id, child := range trie.nodes[node].children {
+			fail := int32(0)
+			if node != 0 {
+				for fail = trie.nodes[node].fail; ; fail = trie.nodes[fail].fail {
+					next, exists := trie.nodes[fail].children[id]
+					if exists {
+						fail = next
+						break
+					}
+					if fail == 0 {
+						break
+					}
+				}
+			}
+			trie.nodes[child].fail = fail
+			if trie.nodes[fail].terminal {
+				trie.nodes[child].output = fail
+			} else {
+				trie.nodes[child].output = trie.nodes[fail].output
+			}
+			queue = append(queue, child)
+		}
+	}
+	trie.built = true
+}
+
+// =================================================================================================
+// method PhraseTrie.scan
+// brief description:
+//   Run the Aho-Corasick automaton over a token stream.
+// input:
+//   ids: The word IDs of the token stream.
+//   visit: The function called with the terminal node of each occurrence of an inserted phrase,
+//          and the index after the last word of the occurrence.
+
+func (trie *PhraseTrie) scan(ids []WordID, visit func(node int32, end int)) {
+	trie.build()
+	state := int32(0)
+	for idx, id := range ids {
+		for {
+			next, exists := trie.nodes[state].children[id]
+			if exists {
+				state = next
+				break
+			}
+			if state == 0 {
+				break
+			}
+			state = trie.nodes[state].fail
+		}
+		node := state
+		if !trie.nodes[node].terminal {
+			node = trie.nodes[node].output
+		}
+		for ; node != 0; node = trie.nodes[node].output {
+			visit(node, idx+1)
+		}
+	}
+}
+
+// =================================================================================================
+// method PhraseTrie.CountOccurrences
+// brief description:
+//   Count all the occurrences of the inserted phrases in a token stream, nested ones included.
+// input:
+//   ids: The word IDs of the token stream.
+// output:
+//   The number of occurrences found.
+
+func (trie *PhraseTrie) CountOccurrences(ids []WordID) int {
+	result := 0
+	trie.scan(ids, func(node int32, end int) {
+		trie.nodes[node].count++
+		result++
+	})
+	return result
+}
+
+// =================================================================================================
+// method PhraseTrie.CountDocument
+// brief description:
+//   Count the occurrences of the inserted phrases in the phrases of a document, and the document
+//   frequencies of the phrases found.
+// input:
+//   phrases: The phrases of the document, e.g. its key phrase candidates.
+
+func (trie *PhraseTrie) CountDocument(phrases []string) {
+	document := trie.numDocuments
+	trie.numDocuments++
+	for _, phrase := range phrases {
+		trie.scan(trie.lookup(phrase), func(node int32, end int) {
+			trie.nodes[node].count++
+			if trie.nodes[node].lastDocument != document {
+				trie.nodes[node].lastDocument = document
+				trie.nodes[node].documentFrequency++
+			}
+		})
+	}
+}
+
+// =================================================================================================
+// method PhraseTrie.NumDocuments
+// brief description:
+//   Get the number of documents counted with CountDocument.
+// output:
+//   The number of documents.
+
+func (trie *PhraseTrie) NumDocuments() int {
+	return trie.numDocuments
+}
+
+// =================================================================================================
+// method PhraseTrie.Has
+// brief description:
+//   Check whether a phrase has been inserted.
+// input:
+//   phrase: The phrase.
+// output:
+//   true if the phrase has been inserted, false otherwise.
+
+func (trie *PhraseTrie) Has(phrase string) bool {
+	node := trie.find(trie.lookup(phrase))
+	return node > 0 && trie.nodes[node].terminal
+}
+
+// =================================================================================================
+// method PhraseTrie.Count
+// brief description:
+//   Get the number of occurrences counted for a phrase.
+// input:
+//   phrase: The phrase.
+// output:
+//   The number of occurrences, or 0 if the phrase has not been inserted.
+
+func (trie *PhraseTrie) Count(phrase string) uint {
+	node := trie.find(trie.lookup(phrase))
+	if node <= 0 {
+		return 0
+	}
+	return trie.nodes[node].count
+}
+
+// =================================================================================================
+// method PhraseTrie.DocumentFrequency
+// brief description:
+//   Get the number of documents in which a phrase has been found.
+// input:
+//   phrase: The phrase.
+// output:
+//   The document frequency, or 0 if the phrase has not been inserted.
+
+func (trie *PhraseTrie) DocumentFrequency(phrase string) uint {
+	node := trie.find(trie.lookup(phrase))
+	if node <= 0 {
+		return 0
+	}
+	return trie.nodes[node].documentFrequency
+}
+
+// =================================================================================================
+// method PhraseTrie.Counts
+// brief description:
+//   Get the numbers of occurrences counted for all the inserted phrases.
+// output:
+//   The number of occurrences of each inserted phrase.
+
+func (trie *PhraseTrie) Counts() map[string]uint {
+	result := map[string]uint{}
+	for node := range trie.nodes {
+		if trie.nodes[node].terminal {
+			result[trie.phraseOf(int32(node))] = trie.nodes[node].count
+		}
+	}
+	return result
+}
+
+// =================================================================================================
+// method PhraseTrie.LongestMatch
+// brief description:
+//   Find the longest inserted phrase that starts at a position of a token stream.
+// input:
+//   ids: The word IDs of the token stream.
+//   begin: The position where the phrase starts.
+// output:
+//   The index after the last word of the longest phrase, or begin if no phrase starts there.
+
+func (trie *PhraseTrie) LongestMatch(ids []WordID, begin int) int {
+	result := begin
+	node := int32(0)
+	for idx := begin; idx < len(ids); idx++ {
+		child, exists := trie.nodes[node].children[ids[idx]]
+		if !exists {
+			break
+		}
+		node = child
+		if trie.nodes[node].terminal {
+			result = idx + 1
+		}
+	}
+	return result
+}
+
+// =================================================================================================
+// method PhraseTrie.ContainedIn
+// brief description:
+//   Find the inserted phrases that are contained in a phrase, the phrase itself included.
+// input:
+//   phrase: The phrase.
+// output:
+//   The inserted phrases that are contiguous parts of the phrase.
+
+func (trie *PhraseTrie) ContainedIn(phrase string) []string {
+	result := []string{}
+	found := map[int32]bool{}
+	trie.scan(trie.lookup(phrase), func(node int32, end int) {
+		if !found[node] {
+			found[node] = true
+			result = append(result, trie.phraseOf(node))
+		}
+	})
+	return result
+}
+
+// =================================================================================================
+// method PhraseTrie.Containing
+// brief description:
+//   Find the inserted phrases that contain a phrase, the phrase itself included.
+// input:
+//   phrase: The phrase.
+// output:
+//   The inserted phrases of which the phrase is a contiguous part.
+
+func (trie *PhraseTrie) Containing(phrase string) []string {
+	// --------------------------------------------------------------------------------------------
+	// step 1: Take the phrases that have the rarest word of the phrase
+	ids := trie.lookup(phrase)
+	var nodes []int32
+	for idx, id := range ids {
+		if id == unknownWordID {
+			return []string{}
+		}
+		if idx == 0 || len(trie.phrasesOfWord[id]) < len(nodes) {
+			nodes = trie.phrasesOfWord[id]
+		}
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 2: Keep those that contain the phrase
+	result := []string{}
+	for _, node := range nodes {
+		nodeIDs := make([]WordID, trie.nodes[node].depth)
+		for n := node; n != 0; n = trie.nodes[n].parent {
+			nodeIDs[trie.nodes[n].depth-1] = trie.nodes[n].word
+		}
+		if IncludesIDs(nodeIDs, ids) {
+			result = append(result, trie.vocabulary.Decode(nodeIDs))
+		}
+	}
+	return result
+}
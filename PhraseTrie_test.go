@@ -0,0 +1,164 @@
+package KeyphraseExtraction
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// occurrences counts the occurrences of a phrase in a text, overlapping ones included, by brute force
+func occurrences(text, phrase string) uint {
+	words, phraseWords := strings.Split(text, " "), strings.Split(phrase, " ")
+	result := uint(0)
+	for begin := 0; begin+len(phraseWords) <= len(words); begin++ {
+		if strings.Join(words[begin:begin+len(phraseWords)], " ") == phrase {
+			result++
+		}
+	}
+	return result
+}
+
+// checkPhraseTrie compares a trie of some phrases with Includes over a text
+func checkPhraseTrie(t *testing.T, phrases []string, text string) {
+	t.Helper()
+	trie := NewPhraseTrie(nil)
+	for _, phrase := range phrases {
+		trie.Insert(phrase)
+	}
+	ids := trie.Vocabulary().Encode(text)
+	numFound := trie.CountOccurrences(ids)
+
+	// the occurrences, overlapping and nested ones included
+	wantTotal := 0
+	for phrase, count := range trie.Counts() {
+		want := occurrences(text, phrase)
+		wantTotal += int(want)
+		if count != want {
+			t.Errorf("%q in %q: %d occurrences, want %d", phrase, text, count, want)
+		}
+	}
+	if numFound != wantTotal {
+		t.Errorf("%q: %d occurrences, want %d", text, numFound, wantTotal)
+	}
+
+	// the longest match at each position
+	words := strings.Split(text, " ")
+	for begin := range words {
+		want := begin
+		for _, phrase := range phrases {
+			if end := begin + strings.Count(phrase, " ") + 1; end > want && end <= len(words) &&
+				strings.Join(words[begin:end], " ") == phrase {
+				want = end
+			}
+		}
+		if got := trie.LongestMatch(ids, begin); got != want {
+			t.Errorf("longest match at %d of %q ends at %d, want %d", begin, text, got, want)
+		}
+	}
+
+	// the inserted phrases contained in the text, and those that contain it
+	wantContainedIn, wantContaining := []string{}, []string{}
+	for phrase := range trie.Counts() {
+		if Includes(text, phrase) {
+			wantContainedIn = append(wantContainedIn, phrase)
+		}
+		if Includes(phrase, text) {
+			wantContaining = append(wantContaining, phrase)
+		}
+	}
+	for _, test := range []struct {
+		name      string
+		got, want []string
+	}{
+		{"ContainedIn", trie.ContainedIn(text), wantContainedIn},
+		{"Containing", trie.Containing(text), wantContaining},
+	} {
+		sort.Strings(test.got)
+		sort.Strings(test.want)
+		if fmt.Sprint(test.got) != fmt.Sprint(test.want) {
+			t.Errorf("%s(%q) = %q, want %q", test.name, text, test.got, test.want)
+		}
+	}
+}
+
+func TestPhraseTrie(t *testing.T) {
+	for _, test := range []struct {
+		phrases []string
+		text    string
+	}{
+		// the failure link of "a b c" leads to "b c", then to "c"
+		{[]string{"a b c", "b c", "c", "b c d"}, "a b c d"},
+		// a mismatch after a partial match restarts at the suffix of the match
+		{[]string{"a b a c", "b a"}, "a b a b a c"},
+		// overlapping occurrences of the same phrase
+		{[]string{"a a"}, "a a a a"},
+		{[]string{"a b a"}, "a b a b a"},
+		// nested phrases of different lengths, and a phrase inserted twice
+		{[]string{"a", "a b", "a b c", "a b", "c"}, "a b c a b"},
+		// words the trie does not know
+		{[]string{"b c"}, "x b c y"},
+		{[]string{"b c"}, "b"},
+		{[]string{"a b c"}, "b c"},
+	} {
+		checkPhraseTrie(t, test.phrases, test.text)
+	}
+}
+
+func TestPhraseTrieRandom(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	randomPhrase := func(maxWords int) string {
+		words := make([]string, 1+random.Intn(maxWords))
+		for i := range words {
+			words[i] = string(rune('a' + random.Intn(3)))
+		}
+		return strings.Join(words, " ")
+	}
+	for iteration := 0; iteration < 100; iteration++ {
+		phrases := make([]string, 1+random.Intn(10))
+		for i := range phrases {
+			phrases[i] = randomPhrase(4)
+		}
+		checkPhraseTrie(t, phrases, randomPhrase(12))
+	}
+}
+
+func TestPhraseTrieCountDocument(t *testing.T) {
+	trie := NewPhraseTrie(nil)
+	for _, phrase := range []string{"neural network", "network"} {
+		trie.Insert(phrase)
+	}
+	trie.CountDocument([]string{"deep neural network", "network model", "unknown"})
+	trie.CountDocument([]string{"network"})
+	if trie.NumDocuments() != 2 {
+		t.Errorf("NumDocuments = %d, want 2", trie.NumDocuments())
+	}
+	for _, test := range []struct {
+		phrase                   string
+		count, documentFrequency uint
+		has                      bool
+	}{
+		{"neural network", 1, 1, true},
+		{"network", 3, 2, true},
+		{"neural", 0, 0, false},
+		{"unknown", 0, 0, false},
+	} {
+		if got := trie.Count(test.phrase); got != test.count {
+			t.Errorf("Count(%q) = %d, want %d", test.phrase, got, test.count)
+		}
+		if got := trie.DocumentFrequency(test.phrase); got != test.documentFrequency {
+			t.Errorf("DocumentFrequency(%q) = %d, want %d", test.phrase, got, test.documentFrequency)
+		}
+		if got := trie.Has(test.phrase); got != test.has {
+			t.Errorf("Has(%q) = %v, want %v", test.phrase, got, test.has)
+		}
+	}
+
+	// a phrase inserted after a scan is found by the next scans
+	trie.Insert("deep neural network")
+	trie.CountDocument([]string{"deep neural network"})
+	if trie.Count("deep neural network") != 1 || trie.Count("network") != 4 {
+		t.Errorf("counts after a new phrase: %v", trie.Counts())
+	}
+}
@@ -0,0 +1,606 @@
+package KeyphraseExtraction
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// SimilaritySource gives the phrases similar to a phrase, as SimTF and SimIDF need them.
+type SimilaritySource interface {
+	// ForEachNeighbor calls visit with each phrase similar to phrase and their similarity, until
+	// visit returns false.
+	ForEachNeighbor(phrase string, visit func(neighbor string, similarity float64) bool)
+}
+
+// SimilarityMap is the raw sparse matrix historically taken by SimTF and SimIDF. Its rows are read
+// as they are, without symmetry nor self-similarity.
+type SimilarityMap map[string]map[string]float64
+
+// =================================================================================================
+// method SimilarityMap.ForEachNeighbor
+// brief description:
+//   Visit the row of a phrase.
+// input:
+//   phrase: the phrase
+//   visit: the function called with each phrase of the row and its similarity
+
+func (similarity SimilarityMap) ForEachNeighbor(phrase string, visit func(neighbor string, similarity float64) bool) {
+	for neighbor, sim := range similarity[phrase] {
+		if !visit(neighbor, sim) {
+			return
+		}
+	}
+}
+
+// similarityMatrixMagic starts the files written by SimilarityMatrix.WriteTo.
+const similarityMatrixMagic = "KPSM1\n"
+
+// maxStoredPhraseLength is the longest phrase read by SimilarityMatrix.ReadFrom, in bytes.
+const maxStoredPhraseLength = 1 << 16
+
+// SimilarityMatrix is a sparse symmetric similarity matrix between phrases, in which each phrase
+// has a similarity of 1 to itself. It is stored in the compressed sparse row (CSR) format once it is
+// read, compacted or first queried. Its read methods are safe for concurrent use, but Set and Prune
+// must not run at the same time as any other method.
+type SimilarityMatrix struct {
+	phrases []string
+	index   map[string]int32
+
+	// pairs holds the similarities being set, by pairKey, until the matrix is compressed
+	pairs map[uint64]float32
+
+	// rowStart, columns and values are the compressed rows, each sorted by columns, or nil if the
+	// matrix is being modified
+	rowStart []int64
+	columns  []int32
+	values   []float32
+
+	// compressed tells the readers that the compressed rows are ready, and mutex serializes the
+	// readers that compress them
+	compressed atomic.Bool
+	mutex      sync.Mutex
+}
+
+// =================================================================================================
+// function pairKey
+// brief description:
+//   Get the key of an unordered pair of phrases.
+// input:
+//   i, j: the indices of the phrases
+// output:
+//   the key, the same for (i, j) and (j, i)
+
+func pairKey(i, j int32) uint64 {
+	if i > j {
+		i, j = j, i
+	}
+	return uint64(i)<<32 | uint64(uint32(j))
+}
+
+// =================================================================================================
+// function NewSimilarityMatrix
+// brief description:
+//   Create an empty similarity matrix.
+// output:
+//   the new matrix
+
+func NewSimilarityMatrix() *SimilarityMatrix {
+	return &SimilarityMatrix{index: map[string]int32{}, pairs: map[uint64]float32{}}
+}
+
+// =================================================================================================
+// function SimilarityMatrixFromMap
+// brief description:
+//   Convert a raw sparse matrix to a similarity matrix.
+// input:
+//   phraseSimilarity: a sparse matrix that gives similarity between strings
+// output:
+//   the similarity matrix, which takes the larger of the two directions of a pair, and ignores the
+//   similarities of phrases to themselves
+
+func SimilarityMatrixFromMap(phraseSimilarity map[string]map[string]float64) *SimilarityMatrix {
+	result := NewSimilarityMatrix()
+	for phrase1, row := range phraseSimilarity {
+		for phrase2, sim := range row {
+			if phrase1 == phrase2 {
+				continue
+			}
+			if other, exists := phraseSimilarity[phrase2][phrase1]; exists && other > sim {
+				sim = other
+			}
+			result.Set(phrase1, phrase2, sim)
+		}
+	}
+	return result
+}
+
+// =================================================================================================
+// method SimilarityMatrix.phraseIndex
+// brief description:
+//   Get the index of a phrase, adding the phrase if it is not there yet.
+// input:
+//   phrase: the phrase
+// output:
+//   the index of the phrase
+
+func (matrix *SimilarityMatrix) phraseIndex(phrase string) int32 {
+	idx, exists := matrix.index[phrase]
+	if !exists {
+		idx = int32(len(matrix.phrases))
+		matrix.index[phrase] = idx
+		matrix.phrases = append(matrix.phrases, phrase)
+	}
+	return idx
+}
+
+// =================================================================================================
+// method SimilarityMatrix.decompress
+// brief description:
+//   Turn the compressed rows back into pairs, so that the matrix can be modified.
+
+func (matrix *SimilarityMatrix) decompress() {
+	if matrix.pairs != nil {
+		return
+	}
+	matrix.pairs = map[uint64]float32{}
+	for i := int32(0); i < int32(len(matrix.phrases)); i++ {
+		for k := matrix.rowStart[i]; k < matrix.rowStart[i+1]; k++ {
+			if j := matrix.columns[k]; j > i {
+				matrix.pairs[pairKey(i, j)] = matrix.values[k]
+			}
+		}
+	}
+	matrix.rowStart, matrix.columns, matrix.values = nil, nil, nil
+	matrix.compressed.Store(false)
+}
+
+// =================================================================================================
+// method SimilarityMatrix.compress
+// brief description:
+//   Store the pairs as compressed rows, each pair in the rows of both of its phrases.
+
+func (matrix *SimilarityMatrix) compress() {
+	if matrix.pairs == nil {
+		return
+	}
+	numPhrases := len(matrix.phrases)
+	degree := make([]int64, numPhrases+1)
+	for key := range matrix.pairs {
+		degree[key>>32]++
+		degree[uint32(key)]++
+	}
+	matrix.rowStart = make([]int64, numPhrases+1)
+	for i := 0; i < numPhrases; i++ {
+		matrix.rowStart[i+1] = matrix.rowStart[i] + degree[i]
+	}
+	matrix.columns = make([]int32, matrix.rowStart[numPhrases])
+	matrix.values = make([]float32, matrix.rowStart[numPhrases])
+	next := make([]int64, numPhrases)
+	copy(next, matrix.rowStart[:numPhrases])
+	for key, sim := range matrix.pairs {
+		i, j := int32(key>>32), int32(uint32(key))
+		matrix.columns[next[i]], matrix.values[next[i]] = j, sim
+		next[i]++
+		matrix.columns[next[j]], matrix.values[next[j]] = i, sim
+		next[j]++
+	}
+	for i := 0; i < numPhrases; i++ {
+		row := csrRow{matrix.columns[matrix.rowStart[i]:matrix.rowStart[i+1]],
+			matrix.values[matrix.rowStart[i]:matrix.rowStart[i+1]]}
+		sort.Sort(row)
+	}
+	matrix.pairs = nil
+	matrix.compressed.Store(true)
+}
+
+// =================================================================================================
+// method SimilarityMatrix.rows
+// brief description:
+//   Make sure that the rows are compressed before reading them, compressing them once if several
+//   goroutines read the matrix.
+
+func (matrix *SimilarityMatrix) rows() {
+	if matrix.compressed.Load() {
+		return
+	}
+	matrix.mutex.Lock()
+	defer matrix.mutex.Unlock()
+	matrix.compress()
+}
+
+// csrRow sorts a compressed row by columns.
+type csrRow struct {
+	columns []int32
+	values  []float32
+}
+
+func (row csrRow) Len() int           { return len(row.columns) }
+func (row csrRow) Less(i, j int) bool { return row.columns[i] < row.columns[j] }
+func (row csrRow) Swap(i, j int) {
+	row.columns[i], row.columns[j] = row.columns[j], row.columns[i]
+	row.values[i], row.values[j] = row.values[j], row.values[i]
+}
+
+// =================================================================================================
+// method SimilarityMatrix.Compact
+// brief description:
+//   Store the matrix in the compressed sparse row format, e.g. before sharing it between
+//   goroutines.
+
+func (matrix *SimilarityMatrix) Compact() {
+	matrix.rows()
+}
+
+// =================================================================================================
+// method SimilarityMatrix.Set
+// brief description:
+//   Set the similarity between two phrases, in both directions.
+// input:
+//   phrase1, phrase2: the phrases. The similarity of a phrase to itself is always 1 and cannot be
+//                     set.
+//   similarity: the similarity
+
+func (matrix *SimilarityMatrix) Set(phrase1, phrase2 string, similarity float64) {
+	if phrase1 == phrase2 {
+		return
+	}
+	matrix.decompress()
+	matrix.pairs[pairKey(matrix.phraseIndex(phrase1), matrix.phraseIndex(phrase2))] = float32(similarity)
+}
+
+// =================================================================================================
+// method SimilarityMatrix.Similarity
+// brief description:
+//   Get the similarity between two phrases.
+// input:
+//   phrase1, phrase2: the phrases
+// output:
+//   the similarity, 1 if the phrases are the same, and 0 if the pair is not in the matrix
+
+func (matrix *SimilarityMatrix) Similarity(phrase1, phrase2 string) float64 {
+	if phrase1 == phrase2 {
+		return 1.0
+	}
+	i, exists1 := matrix.index[phrase1]
+	j, exists2 := matrix.index[phrase2]
+	if !exists1 || !exists2 {
+		return 0.0
+	}
+	matrix.rows()
+	columns := matrix.columns[matrix.rowStart[i]:matrix.rowStart[i+1]]
+	k := sort.Search(len(columns), func(k int) bool { return columns[k] >= j })
+	if k < len(columns) && columns[k] == j {
+		return float64(matrix.values[matrix.rowStart[i]+int64(k)])
+	}
+	return 0.0
+}
+
+// =================================================================================================
+// method SimilarityMatrix.ForEachNeighbor
+// brief description:
+//   Visit the phrases similar to a phrase, starting with the phrase itself.
+// input:
+//   phrase: the phrase, which needs not be in the matrix
+//   visit: the function called with each phrase similar to phrase and their similarity, until it
+//          returns false
+
+func (matrix *SimilarityMatrix) ForEachNeighbor(phrase string, visit func(neighbor string, similarity float64) bool) {
+	if !visit(phrase, 1.0) {
+		return
+	}
+	i, exists := matrix.index[phrase]
+	if !exists {
+		return
+	}
+	matrix.rows()
+	for k := matrix.rowStart[i]; k < matrix.rowStart[i+1]; k++ {
+		if !visit(matrix.phrases[matrix.columns[k]], float64(matrix.values[k])) {
+			return
+		}
+	}
+}
+
+// =================================================================================================
+// method SimilarityMatrix.Len
+// brief description:
+//   Get the number of phrases in the matrix.
+// output:
+//   the number of phrases
+
+func (matrix *SimilarityMatrix) Len() int {
+	return len(matrix.phrases)
+}
+
+// =================================================================================================
+// method SimilarityMatrix.NumPairs
+// brief description:
+//   Get the number of pairs of different phrases in the matrix.
+// output:
+//   the number of pairs
+
+func (matrix *SimilarityMatrix) NumPairs() int {
+	matrix.rows()
+	return len(matrix.columns) / 2
+}
+
+// =================================================================================================
+// method SimilarityMatrix.Prune
+// brief description:
+//   Drop the weak pairs of the matrix.
+// input:
+//   threshold: the lowest similarity kept
+//   topK: the largest number of neighbors of each phrase, or 0 for no limit. To keep the matrix
+//         symmetric, a pair is kept if it is among the top k of either of its phrases.
+
+func (matrix *SimilarityMatrix) Prune(threshold float64, topK int) {
+	// --------------------------------------------------------------------------------------------
+	// step 1: find the pairs among the top k of their phrases
+	matrix.compress()
+	kept := map[uint64]float32{}
+	for i := int32(0); i < int32(len(matrix.phrases)); i++ {
+		neighbors := []phraseNeighbor{}
+		for k := matrix.rowStart[i]; k < matrix.rowStart[i+1]; k++ {
+			if float64(matrix.values[k]) >= threshold {
+				neighbors = append(neighbors, phraseNeighbor{int(matrix.columns[k]), float64(matrix.values[k])})
+			}
+		}
+		if topK > 0 && len(neighbors) > topK {
+			sort.Slice(neighbors, func(a, b int) bool {
+				return neighbors[a].similarity > neighbors[b].similarity
+			})
+			neighbors = neighbors[:topK]
+		}
+		for _, neighbor := range neighbors {
+			kept[pairKey(i, int32(neighbor.index))] = float32(neighbor.similarity)
+		}
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 2: keep them only
+	matrix.rowStart, matrix.columns, matrix.values = nil, nil, nil
+	matrix.pairs = kept
+	matrix.compressed.Store(false)
+}
+
+// =================================================================================================
+// method SimilarityMatrix.Validate
+// brief description:
+//   Check that the similarities of the matrix are finite and within [-1, 1].
+// output:
+//   the error about the first bad pair found, or nil
+
+func (matrix *SimilarityMatrix) Validate() error {
+	matrix.rows()
+	for i := int32(0); i < int32(len(matrix.phrases)); i++ {
+		for k := matrix.rowStart[i]; k < matrix.rowStart[i+1]; k++ {
+			sim := float64(matrix.values[k])
+			if math.IsNaN(sim) || sim < -1.0 || sim > 1.0 {
+				return fmt.Errorf("KeyphraseExtraction: similarity between %q and %q is %v",
+					matrix.phrases[i], matrix.phrases[matrix.columns[k]], sim)
+			}
+		}
+	}
+	return nil
+}
+
+// =================================================================================================
+// function ValidateSimilarityMap
+// brief description:
+//   Check that a raw sparse matrix is symmetric, with a similarity of 1 of each phrase to itself
+//   where it is given, and finite similarities within [-1, 1].
+// input:
+//   phraseSimilarity: a sparse matrix that gives similarity between strings
+// output:
+//   the error about the first bad entry found, or nil
+
+func ValidateSimilarityMap(phraseSimilarity map[string]map[string]float64) error {
+	for phrase1, row := range phraseSimilarity {
+		for phrase2, sim := range row {
+			if math.IsNaN(sim) || sim < -1.0 || sim > 1.0 {
+				return fmt.Errorf("KeyphraseExtraction: similarity between %q and %q is %v", phrase1, phrase2, sim)
+			}
+			if phrase1 == phrase2 {
+				if sim != 1.0 {
+					return fmt.Errorf("KeyphraseExtraction: similarity of %q to itself is %v", phrase1, sim)
+				}
+				continue
+			}
+			if other, exists := phraseSimilarity[phrase2][phrase1]; !exists || other != sim {
+				return fmt.Errorf("KeyphraseExtraction: similarity between %q and %q is not symmetric",
+					phrase1, phrase2)
+			}
+		}
+	}
+	return nil
+}
+
+// =================================================================================================
+// method SimilarityMatrix.ToMap
+// brief description:
+//   Convert the matrix to a raw sparse matrix.
+// output:
+//   the sparse matrix, with both directions of each pair and a similarity of 1 of each phrase to
+//   itself
+
+func (matrix *SimilarityMatrix) ToMap() map[string]map[string]float64 {
+	result := make(map[string]map[string]float64, len(matrix.phrases))
+	for _, phrase := range matrix.phrases {
+		row := map[string]float64{}
+		matrix.ForEachNeighbor(phrase, func(neighbor string, similarity float64) bool {
+			row[neighbor] = similarity
+			return true
+		})
+		result[phrase] = row
+	}
+	return result
+}
+
+// =================================================================================================
+// method SimilarityMatrix.WriteTo
+// brief description:
+//   Write the matrix in a compact binary format: the phrases, then for each phrase the pairs with
+//   the following phrases, as delta-coded columns and float32 similarities.
+// input:
+//   writer: the writer
+// output:
+//   the number of bytes written, and the error if any
+
+func (matrix *SimilarityMatrix) WriteTo(writer io.Writer) (int64, error) {
+	matrix.rows()
+	counter := &countingWriter{writer: bufio.NewWriter(writer)}
+	buffer := make([]byte, binary.MaxVarintLen64)
+	putUvarint := func(value uint64) {
+		counter.Write(buffer[:binary.PutUvarint(buffer, value)])
+	}
+
+	counter.Write([]byte(similarityMatrixMagic))
+	putUvarint(uint64(len(matrix.phrases)))
+	for _, phrase := range matrix.phrases {
+		putUvarint(uint64(len(phrase)))
+		counter.Write([]byte(phrase))
+	}
+	for i := int32(0); i < int32(len(matrix.phrases)); i++ {
+		// the row is sorted by columns, so the following phrases are at its end
+		begin := matrix.rowStart[i]
+		for begin < matrix.rowStart[i+1] && matrix.columns[begin] <= i {
+			begin++
+		}
+		putUvarint(uint64(matrix.rowStart[i+1] - begin))
+		previous := i
+		for k := begin; k < matrix.rowStart[i+1]; k++ {
+			putUvarint(uint64(matrix.columns[k] - previous))
+			previous = matrix.columns[k]
+			binary.LittleEndian.PutUint32(buffer, math.Float32bits(matrix.values[k]))
+			counter.Write(buffer[:4])
+		}
+	}
+	if counter.err == nil {
+		counter.err = counter.writer.Flush()
+	}
+	return counter.count, counter.err
+}
+
+// countingWriter counts the bytes written, and keeps the first error.
+type countingWriter struct {
+	writer *bufio.Writer
+	count  int64
+	err    error
+}
+
+func (counter *countingWriter) Write(data []byte) (int, error) {
+	if counter.err != nil {
+		return 0, counter.err
+	}
+	n, err := counter.writer.Write(data)
+	counter.count += int64(n)
+	counter.err = err
+	return n, err
+}
+
+// =================================================================================================
+// method SimilarityMatrix.ReadFrom
+// brief description:
+//   Replace the content of the matrix with a matrix written by WriteTo.
+// input:
+//   reader: the reader
+// output:
+//   the number of bytes read, and the error if any
+
+func (matrix *SimilarityMatrix) ReadFrom(reader io.Reader) (int64, error) {
+	// --------------------------------------------------------------------------------------------
+	// step 1: read the phrases
+	counter := &countingReader{reader: bufio.NewReader(reader)}
+	fail := func(err error) (int64, error) {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return counter.count, fmt.Errorf("KeyphraseExtraction: similarity matrix: %v", err)
+	}
+	magic := make([]byte, len(similarityMatrixMagic))
+	if _, err := io.ReadFull(counter, magic); err != nil {
+		return fail(err)
+	}
+	if string(magic) != similarityMatrixMagic {
+		return fail(fmt.Errorf("bad magic %q", magic))
+	}
+	numPhrases, err := binary.ReadUvarint(counter)
+	if err != nil {
+		return fail(err)
+	}
+	if numPhrases > math.MaxInt32 {
+		return fail(fmt.Errorf("too many phrases: %d", numPhrases))
+	}
+	result := NewSimilarityMatrix()
+	for i := uint64(0); i < numPhrases; i++ {
+		length, err := binary.ReadUvarint(counter)
+		if err != nil {
+			return fail(err)
+		}
+		if length > maxStoredPhraseLength {
+			return fail(fmt.Errorf("phrase of %d bytes is too long", length))
+		}
+		phrase := make([]byte, length)
+		if _, err := io.ReadFull(counter, phrase); err != nil {
+			return fail(err)
+		}
+		if int(result.phraseIndex(string(phrase))) != int(i) {
+			return fail(fmt.Errorf("duplicate phrase %q", phrase))
+		}
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 2: read the pairs
+	value := make([]byte, 4)
+	for i := int32(0); i < int32(numPhrases); i++ {
+		numPairs, err := binary.ReadUvarint(counter)
+		if err != nil {
+			return fail(err)
+		}
+		column := uint64(i)
+		for k := uint64(0); k < numPairs; k++ {
+			delta, err := binary.ReadUvarint(counter)
+			if err != nil {
+				return fail(err)
+			}
+			column += delta
+			if delta == 0 || column >= numPhrases {
+				return fail(fmt.Errorf("bad column %d in row %d", column, i))
+			}
+			if _, err := io.ReadFull(counter, value); err != nil {
+				return fail(err)
+			}
+			result.pairs[pairKey(i, int32(column))] = math.Float32frombits(binary.LittleEndian.Uint32(value))
+		}
+	}
+	result.compress()
+	matrix.phrases, matrix.index, matrix.pairs = result.phrases, result.index, nil
+	matrix.rowStart, matrix.columns, matrix.values = result.rowStart, result.columns, result.values
+	matrix.compressed.Store(true)
+	return counter.count, nil
+}
+
+// countingReader counts the bytes read.
+type countingReader struct {
+	reader *bufio.Reader
+	count  int64
+}
+
+func (counter *countingReader) Read(data []byte) (int, error) {
+	n, err := counter.reader.Read(data)
+	counter.count += int64(n)
+	return n, err
+}
+
+func (counter *countingReader) ReadByte() (byte, error) {
+	b, err := counter.reader.ReadByte()
+	if err == nil {
+		counter.count++
+	}
+	return b, err
+}
@@ -0,0 +1,83 @@
+package KeyphraseExtraction
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func newTestSimilarityMatrix() *SimilarityMatrix {
+	matrix := NewSimilarityMatrix()
+	for i := 0; i < 50; i++ {
+		for j := i + 1; j < 50; j += 3 {
+			matrix.Set(fmt.Sprintf("phrase %d", i), fmt.Sprintf("phrase %d", j), float64(i+j)/100.0)
+		}
+	}
+	return matrix
+}
+
+func TestSimilarityMatrixRoundTrip(t *testing.T) {
+	matrix := newTestSimilarityMatrix()
+	var buffer bytes.Buffer
+	written, err := matrix.WriteTo(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written != int64(buffer.Len()) {
+		t.Errorf("WriteTo returned %d, wrote %d bytes", written, buffer.Len())
+	}
+
+	loaded := NewSimilarityMatrix()
+	read, err := loaded.ReadFrom(bytes.NewReader(buffer.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read != written {
+		t.Errorf("ReadFrom returned %d, want %d", read, written)
+	}
+	if !loaded.compressed.Load() {
+		t.Error("matrix is not compressed after ReadFrom")
+	}
+	if loaded.Len() != matrix.Len() || loaded.NumPairs() != matrix.NumPairs() {
+		t.Fatalf("loaded %d phrases and %d pairs, want %d and %d",
+			loaded.Len(), loaded.NumPairs(), matrix.Len(), matrix.NumPairs())
+	}
+	for i := 0; i < 50; i++ {
+		for j := 0; j < 50; j++ {
+			phrase1, phrase2 := fmt.Sprintf("phrase %d", i), fmt.Sprintf("phrase %d", j)
+			if got, want := loaded.Similarity(phrase1, phrase2), matrix.Similarity(phrase1, phrase2); got != want {
+				t.Fatalf("Similarity(%q, %q) = %v, want %v", phrase1, phrase2, got, want)
+			}
+		}
+	}
+}
+
+func TestSimilarityMatrixReadFromLongPhrase(t *testing.T) {
+	buffer := []byte(similarityMatrixMagic)
+	buffer = binary.AppendUvarint(buffer, 1)
+	buffer = binary.AppendUvarint(buffer, 1<<40)
+	if _, err := NewSimilarityMatrix().ReadFrom(bytes.NewReader(buffer)); err == nil {
+		t.Error("ReadFrom accepted a phrase of 1 TiB")
+	}
+}
+
+func TestSimilarityMatrixConcurrentReads(t *testing.T) {
+	// run with -race: the first readers compress the matrix
+	matrix := newTestSimilarityMatrix()
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				phrase := fmt.Sprintf("phrase %d", (i+g)%50)
+				matrix.ForEachNeighbor(phrase, func(string, float64) bool { return true })
+				matrix.Similarity(phrase, "phrase 1")
+				matrix.NumPairs()
+			}
+		}(g)
+	}
+	wg.Wait()
+}
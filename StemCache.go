@@ -0,0 +1,263 @@
+package KeyphraseExtraction
+
+import (
+	"bufio"
+	"container/list"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/kljensen/snowball/english"
+)
+
+// DefaultStemCacheCapacity is the number of words kept by a StemCache created with a capacity of 0.
+const DefaultStemCacheCapacity = 1 << 16
+
+const numStemCacheShards = 16
+
+// stemCacheEntry is a word and its stem, held by the LRU list of a shard.
+type stemCacheEntry struct {
+	word string
+	stem string
+}
+
+// stemCacheShard is a part of a StemCache with its own lock and its own LRU list.
+type stemCacheShard struct {
+	mutex    sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List
+}
+
+// StemCache caches the stems of words, evicting the least recently used words when it is full. It
+// is safe for concurrent use.
+type StemCache struct {
+	shards [numStemCacheShards]stemCacheShard
+}
+
+// stemCacheEscaper and stemCacheUnescaper escape the tabs and the line breaks of the words and
+// the stems written by WriteTo.
+var stemCacheEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+var stemCacheUnescaper = strings.NewReplacer(`\\`, `\`, `\t`, "\t", `\n`, "\n", `\r`, "\r")
+
+// defaultStemCache is the cache used by StemPhrases and by the extractors without their own caches.
+var defaultStemCache = NewStemCache(0)
+
+// =================================================================================================
+// function NewStemCache
+// brief description:
+//   Create an empty stem cache.
+// input:
+//   capacity: The maximum number of words kept, or 0 for DefaultStemCacheCapacity.
+// output:
+//   The new cache.
+
+func NewStemCache(capacity int) *StemCache {
+	if capacity <= 0 {
+		capacity = DefaultStemCacheCapacity
+	}
+	cache := &StemCache{}
+	for i := range cache.shards {
+		shardCapacity := capacity / numStemCacheShards
+		if i < capacity%numStemCacheShards {
+			shardCapacity++
+		}
+		if shardCapacity < 1 {
+			shardCapacity = 1
+		}
+		cache.shards[i] = stemCacheShard{
+			capacity: shardCapacity,
+			entries:  map[string]*list.Element{},
+			order:    list.New(),
+		}
+	}
+	return cache
+}
+
+// =================================================================================================
+// method StemCache.shard
+// brief description:
+//   Get the shard of a word.
+// input:
+//   word: The word.
+// output:
+//   The shard that holds the word.
+
+func (cache *StemCache) shard(word string) *stemCacheShard {
+	// FNV-1a, inlined so that hashing a word does not allocate
+	hash := uint32(2166136261)
+	for i := 0; i < len(word); i++ {
+		hash ^= uint32(word[i])
+		hash *= 16777619
+	}
+	return &cache.shards[hash%numStemCacheShards]
+}
+
+// =================================================================================================
+// method StemCache.put
+// brief description:
+//   Add a word and its stem to the cache, evicting the least recently used word if it is full.
+// input:
+//   word: The word.
+//   stem: The stem of the word.
+
+func (cache *StemCache) put(word, stem string) {
+	shard := cache.shard(word)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+	if element, exists := shard.entries[word]; exists {
+		element.Value.(*stemCacheEntry).stem = stem
+		shard.order.MoveToFront(element)
+		return
+	}
+	shard.entries[word] = shard.order.PushFront(&stemCacheEntry{word: word, stem: stem})
+	if shard.order.Len() > shard.capacity {
+		oldest := shard.order.Back()
+		shard.order.Remove(oldest)
+		delete(shard.entries, oldest.Value.(*stemCacheEntry).word)
+	}
+}
+
+// =================================================================================================
+// method StemCache.Stem
+// brief description:
+//   Stem a word with Snowball stemmer, using the cached stem if there is one.
+// input:
+//   word: The word.
+// output:
+//   The stem of the word.
+
+func (cache *StemCache) Stem(word string) string {
+	shard := cache.shard(word)
+	shard.mutex.Lock()
+	if element, exists := shard.entries[word]; exists {
+		shard.order.MoveToFront(element)
+		stem := element.Value.(*stemCacheEntry).stem
+		shard.mutex.Unlock()
+		return stem
+	}
+	shard.mutex.Unlock()
+
+	stem := english.Stem(word, false)
+	cache.put(word, stem)
+	return stem
+}
+
+// =================================================================================================
+// method StemCache.Warm
+// brief description:
+//   Stem some words ahead, so that they are in the cache.
+// input:
+//   words: The words, e.g. the most frequent words of a corpus.
+
+func (cache *StemCache) Warm(words []string) {
+	for _, word := range words {
+		cache.Stem(word)
+	}
+}
+
+// =================================================================================================
+// method StemCache.Len
+// brief description:
+//   Get the number of words in the cache.
+// output:
+//   The number of words.
+
+func (cache *StemCache) Len() int {
+	result := 0
+	for i := range cache.shards {
+		cache.shards[i].mutex.Lock()
+		result += cache.shards[i].order.Len()
+		cache.shards[i].mutex.Unlock()
+	}
+	return result
+}
+
+// =================================================================================================
+// method StemCache.WriteTo
+// brief description:
+//   Write the cached words and their stems, one "word<TAB>stem" line each, e.g. next to a saved IDF
+//   model, so that the cache can be warmed with ReadFrom. The backslashes, tabs and line breaks of
+//   the words and stems are escaped as in Go strings.
+// input:
+//   writer: The writer.
+// output:
+//   The number of bytes written, and the error if any.
+
+func (cache *StemCache) WriteTo(writer io.Writer) (int64, error) {
+	buffered := bufio.NewWriter(writer)
+	var result int64
+	for i := range cache.shards {
+		shard := &cache.shards[i]
+		shard.mutex.Lock()
+		entries := make([]stemCacheEntry, 0, shard.order.Len())
+		// from the least recently used, so that ReadFrom leaves the most recently used at the front
+		for element := shard.order.Back(); element != nil; element = element.Prev() {
+			entries = append(entries, *element.Value.(*stemCacheEntry))
+		}
+		shard.mutex.Unlock()
+
+		for _, entry := range entries {
+			n, err := fmt.Fprintf(buffered, "%s\t%s\n", stemCacheEscaper.Replace(entry.word),
+				stemCacheEscaper.Replace(entry.stem))
+			result += int64(n)
+			if err != nil {
+				return result, err
+			}
+		}
+	}
+	return result, buffered.Flush()
+}
+
+// =================================================================================================
+// method StemCache.ReadFrom
+// brief description:
+//   Add the words and stems written by WriteTo to the cache.
+// input:
+//   reader: The reader.
+// output:
+//   The number of bytes read, and the error if any.
+
+func (cache *StemCache) ReadFrom(reader io.Reader) (int64, error) {
+	input := &countingReader{reader: bufio.NewReader(reader)}
+	scanner := bufio.NewScanner(input)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		// a carriage return of the text is escaped, so that a last one ends a Windows line
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != 2 {
+			return input.count, fmt.Errorf("KeyphraseExtraction: stem cache line %d: expected word and stem", lineNumber)
+		}
+		cache.put(stemCacheUnescaper.Replace(fields[0]), stemCacheUnescaper.Replace(fields[1]))
+	}
+	return input.count, scanner.Err()
+}
+
+// =================================================================================================
+// method StemCache.stemPhrases
+// brief description:
+//   Stem the words in each candidate phrases with Snowball stemmer, using the cache.
+// input:
+//   phrases: A vector of candidate phrases.
+// output:
+//   The stemmed candidate phrases.
+
+func (cache *StemCache) stemPhrases(phrases [][]string) []string {
+	result := make([]string, 0, len(phrases))
+	var builder strings.Builder
+	for _, phrase := range phrases {
+		builder.Reset()
+		for i, word := range phrase {
+			if i > 0 {
+				builder.WriteByte(' ')
+			}
+			builder.WriteString(cache.Stem(word))
+		}
+		result = append(result, builder.String())
+	}
+	return result
+}
@@ -0,0 +1,103 @@
+package KeyphraseExtraction
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kljensen/snowball/english"
+)
+
+func TestStemCacheEviction(t *testing.T) {
+	cache := NewStemCache(2 * numStemCacheShards)
+	for i := 0; i < 1000; i++ {
+		cache.Stem(fmt.Sprintf("word%d", i))
+	}
+	if cache.Len() != 2*numStemCacheShards {
+		t.Errorf("Len = %d, want %d", cache.Len(), 2*numStemCacheShards)
+	}
+
+	// three words of the same shard, which holds two of them
+	words := []string{}
+	for i := 0; len(words) < 3; i++ {
+		word := fmt.Sprintf("network%d", i)
+		if len(words) == 0 || cache.shard(word) == cache.shard(words[0]) {
+			words = append(words, word)
+		}
+	}
+	shard := cache.shard(words[0])
+	cache.Stem(words[0])
+	cache.Stem(words[1])
+	cache.Stem(words[0])
+	cache.Stem(words[2])
+	for i, want := range []bool{true, false, true} {
+		if _, cached := shard.entries[words[i]]; cached != want {
+			t.Errorf("%q cached: %v, want %v", words[i], cached, want)
+		}
+	}
+}
+
+func TestStemCacheConcurrentStem(t *testing.T) {
+	// run with -race
+	cache := NewStemCache(numStemCacheShards)
+	words := []string{"networks", "running", "graphs", "models", "learning", "trees", "classifiers"}
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				word := words[(i+g)%len(words)]
+				if got, want := cache.Stem(word), english.Stem(word, false); got != want {
+					t.Errorf("Stem(%q) = %q, want %q", word, got, want)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
+func TestStemCacheRoundTrip(t *testing.T) {
+	cache := NewStemCache(0)
+	cache.Warm([]string{"networks", "graphs"})
+	cache.put("tab\tword", "tab\tstem")
+	cache.put("line\nword", `back\slash`)
+	var buffer bytes.Buffer
+	written, err := cache.WriteTo(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written != int64(buffer.Len()) {
+		t.Errorf("WriteTo returned %d, wrote %d bytes", written, buffer.Len())
+	}
+
+	for _, text := range []string{
+		buffer.String(),
+		strings.ReplaceAll(buffer.String(), "\n", "\r\n"),
+		strings.TrimSuffix(buffer.String(), "\n"),
+	} {
+		loaded := NewStemCache(0)
+		read, err := loaded.ReadFrom(strings.NewReader(text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if read != int64(len(text)) {
+			t.Errorf("ReadFrom returned %d, want %d", read, len(text))
+		}
+		if loaded.Len() != 4 {
+			t.Errorf("loaded %d words, want 4", loaded.Len())
+		}
+		for word, want := range map[string]string{
+			"tab\tword":  "tab\tstem",
+			"line\nword": `back\slash`,
+			"networks":   english.Stem("networks", false),
+		} {
+			if got := loaded.Stem(word); got != want {
+				t.Errorf("Stem(%q) = %q after ReadFrom, want %q", word, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,570 @@
+package KeyphraseExtraction
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// TaxonomyRelation tells why a phrase is broader than another in a taxonomy.
+type TaxonomyRelation int
+
+const (
+	// RelationHead links a phrase to the phrase made of its last words, which keeps its head word,
+	// e.g. "neural network" is broader than "convolutional neural network".
+	RelationHead TaxonomyRelation = iota
+
+	// RelationSimilarity links a phrase to a similar phrase that occurs in fewer documents. Similar
+	// phrases that occur in as many documents are not linked, as neither is broader.
+	RelationSimilarity
+
+	// RelationSubsumption links a phrase to a phrase of fewer documents that mostly occurs with it.
+	RelationSubsumption
+)
+
+// =================================================================================================
+// method TaxonomyRelation.String
+// brief description:
+//   Get the name of a taxonomy relation.
+// output:
+//   "head", "similarity" or "subsumption".
+
+func (relation TaxonomyRelation) String() string {
+	switch relation {
+	case RelationHead:
+		return "head"
+	case RelationSimilarity:
+		return "similarity"
+	case RelationSubsumption:
+		return "subsumption"
+	}
+	return fmt.Sprintf("TaxonomyRelation(%d)", int(relation))
+}
+
+// TaxonomyOptions controls the induction of a taxonomy.
+type TaxonomyOptions struct {
+	// MinDocuments is the smallest number of documents that a candidate or an n-gram of a candidate
+	// must occur in to be a node. 0 means 2.
+	MinDocuments int
+
+	// Similarity links the similar phrases, e.g. a SimilarityMatrix. nil means no links by
+	// similarity.
+	Similarity SimilaritySource
+
+	// MinSimilarity is the lowest similarity of two linked phrases. 0 means 0.5.
+	MinSimilarity float64
+
+	// Subsumption is the lowest fraction of the documents of a phrase that must have a broader
+	// phrase for co-occurrence subsumption, e.g. 0.8. 0 links no phrases by subsumption, which
+	// saves the counting of the co-occurrences, whose time grows with the square of the number of
+	// phrases of a document.
+	Subsumption float64
+}
+
+// TaxonomyNode is a keyphrase of a taxonomy.
+type TaxonomyNode struct {
+	// Phrase is the stemmed phrase, and Label its most frequent surface form.
+	Phrase string
+	Label  string
+
+	// NumDocuments is the number of documents that have the phrase.
+	NumDocuments int
+
+	// Broader and Narrower are the indices of the nodes of the broader and narrower phrases.
+	Broader  []int
+	Narrower []int
+}
+
+// TaxonomyEdge links a broader phrase to a narrower phrase.
+type TaxonomyEdge struct {
+	// Broader and Narrower are the indices of the nodes.
+	Broader  int
+	Narrower int
+
+	// Relation tells why the link has been made, and Weight how strong it is: 1 for RelationHead,
+	// the similarity for RelationSimilarity, and the fraction of the documents of the narrower
+	// phrase that have the broader phrase for RelationSubsumption.
+	Relation TaxonomyRelation
+	Weight   float64
+}
+
+// Taxonomy is a directed acyclic hierarchy of keyphrases, in which the nodes are sorted from the
+// broadest to the narrowest, so that every edge goes from a node to a later node.
+type Taxonomy struct {
+	Nodes []TaxonomyNode
+	Edges []TaxonomyEdge
+	index map[string]int
+}
+
+// =================================================================================================
+// function taxonomyPhrases
+// brief description:
+//   Find the phrases of the documents that can be nodes of a taxonomy, i.e. their candidates and
+//   the n-grams of their candidates.
+// input:
+//   documents: the processed documents
+// output:
+//   the phrases of each document, and the number of times each surface form labels each phrase
+
+func taxonomyPhrases(documents []*Document) ([]map[string]bool, map[string]map[string]int) {
+	phrasesOfDocuments := make([]map[string]bool, len(documents))
+	labels := map[string]map[string]int{}
+	addLabel := func(phrase, label string) {
+		if labels[phrase] == nil {
+			labels[phrase] = map[string]int{}
+		}
+		labels[phrase][label]++
+	}
+	for d, document := range documents {
+		phrasesOfDocuments[d] = map[string]bool{}
+		for _, candidate := range document.Candidates {
+			stems := strings.Split(candidate.Phrase, " ")
+			surfaces := alignStems(document, candidate)
+			numWords := len(stems)
+			for i := 0; i < numWords; i++ {
+				for j := i + 1; j <= numWords; j++ {
+					phrase := strings.Join(stems[i:j], " ")
+					phrasesOfDocuments[d][phrase] = true
+					if i == 0 && j == numWords {
+						addLabel(phrase, document.SurfaceForm(candidate))
+					} else if surfaces != nil {
+						addLabel(phrase, strings.Join(surfaces[i:j], " "))
+					}
+				}
+			}
+		}
+	}
+	return phrasesOfDocuments, labels
+}
+
+// =================================================================================================
+// function subsumingNodes
+// brief description:
+//   Count the documents of each node of a taxonomy with each broader node that occurs in enough of
+//   its documents to subsume it.
+// input:
+//   taxonomy: the taxonomy, whose nodes are sorted from the broadest to the narrowest
+//   phrasesOfDocuments: the phrases of each document
+//   subsumption: the lowest fraction of the documents of a node that must have a broader node
+// output:
+//   the number of documents of each node with each broader node that reaches the fraction
+// notes:
+//   A broader node in t of the n documents of a node is in at least one of any n - t + 1 of its
+//   documents, so that only the nodes of its n - t + 1 documents with the fewest phrases are
+//   counted (prefix filtering), each in its n documents.
+
+func subsumingNodes(taxonomy *Taxonomy, phrasesOfDocuments []map[string]bool,
+	subsumption float64) []map[int]int {
+	// the documents of each node, from the one with the fewest phrases
+	documentsOfNodes := make([][]int, len(taxonomy.Nodes))
+	for d, phrases := range phrasesOfDocuments {
+		for phrase := range phrases {
+			if i, exists := taxonomy.index[phrase]; exists {
+				documentsOfNodes[i] = append(documentsOfNodes[i], d)
+			}
+		}
+	}
+	result := make([]map[int]int, len(taxonomy.Nodes))
+	for i, documents := range documentsOfNodes {
+		result[i] = map[int]int{}
+		sort.Slice(documents, func(a, b int) bool {
+			sizeA, sizeB := len(phrasesOfDocuments[documents[a]]), len(phrasesOfDocuments[documents[b]])
+			if sizeA != sizeB {
+				return sizeA < sizeB
+			}
+			return documents[a] < documents[b]
+		})
+
+		// the smallest number of documents that reaches the fraction
+		numDocuments := len(documents)
+		minCount := int(math.Ceil(subsumption * float64(numDocuments)))
+		for minCount > 1 && float64(minCount-1)/float64(numDocuments) >= subsumption {
+			minCount--
+		}
+		for minCount <= numDocuments && float64(minCount)/float64(numDocuments) < subsumption {
+			minCount++
+		}
+		if minCount < 1 {
+			minCount = 1
+		}
+		if minCount > numDocuments {
+			continue
+		}
+
+		// the broader nodes of the prefix, counted in all the documents of the node
+		counted := map[int]bool{}
+		for _, d := range documents[:numDocuments-minCount+1] {
+			for phrase := range phrasesOfDocuments[d] {
+				j, exists := taxonomy.index[phrase]
+				if !exists || j >= i || counted[j] {
+					continue
+				}
+				counted[j] = true
+				count := 0
+				for _, other := range documents {
+					if phrasesOfDocuments[other][phrase] {
+						count++
+					}
+				}
+				if count >= minCount {
+					result[i][j] = count
+				}
+			}
+		}
+	}
+	return result
+}
+
+// =================================================================================================
+// function InduceTaxonomy
+// brief description:
+//   Build a hierarchy of the keyphrases of some documents from the containment of their head
+//   words, their similarity and their co-occurrences.
+// input:
+//   documents: the processed documents
+//   options: the options of the induction
+// output:
+//   the taxonomy, without the links implied by longer paths
+// notes:
+//   A phrase is only linked to a narrower phrase that occurs in as many or fewer documents, which
+//   keeps the hierarchy acyclic, and to a similar phrase only if it occurs in fewer documents. The
+//   reference for co-occurrence subsumption is:
+//   Sanderson, M., & Croft, B. (1999). Deriving concept hierarchies from text. In SIGIR
+//   (pp. 206-213).
+
+func InduceTaxonomy(documents []*Document, options TaxonomyOptions) *Taxonomy {
+	// --------------------------------------------------------------------------------------------
+	// step 1: find the phrases that occur in enough documents
+	minDocuments := options.MinDocuments
+	if minDocuments <= 0 {
+		minDocuments = 2
+	}
+	minSimilarity := options.MinSimilarity
+	if minSimilarity <= 0 {
+		minSimilarity = 0.5
+	}
+	phrasesOfDocuments, labels := taxonomyPhrases(documents)
+	documentFrequency := map[string]int{}
+	for _, phrases := range phrasesOfDocuments {
+		for phrase := range phrases {
+			documentFrequency[phrase]++
+		}
+	}
+	taxonomy := &Taxonomy{Nodes: []TaxonomyNode{}, Edges: []TaxonomyEdge{}, index: map[string]int{}}
+	for phrase, df := range documentFrequency {
+		if df >= minDocuments {
+			taxonomy.Nodes = append(taxonomy.Nodes, TaxonomyNode{Phrase: phrase, NumDocuments: df})
+		}
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 2: sort the nodes from the broadest to the narrowest and label them
+	numWords := func(phrase string) int { return strings.Count(phrase, " ") + 1 }
+	sort.Slice(taxonomy.Nodes, func(i, j int) bool {
+		nodeI, nodeJ := taxonomy.Nodes[i], taxonomy.Nodes[j]
+		if nodeI.NumDocuments != nodeJ.NumDocuments {
+			return nodeI.NumDocuments > nodeJ.NumDocuments
+		}
+		if numWords(nodeI.Phrase) != numWords(nodeJ.Phrase) {
+			return numWords(nodeI.Phrase) < numWords(nodeJ.Phrase)
+		}
+		return nodeI.Phrase < nodeJ.Phrase
+	})
+	for i := range taxonomy.Nodes {
+		node := &taxonomy.Nodes[i]
+		taxonomy.index[node.Phrase] = i
+		node.Label = node.Phrase
+		bestCount := 0
+		for label, count := range labels[node.Phrase] {
+			if count > bestCount || count == bestCount && label < node.Label {
+				node.Label, bestCount = label, count
+			}
+		}
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 3: count the documents of each node with the broader nodes that can subsume it
+	cooccurrences := make([]map[int]int, len(taxonomy.Nodes))
+	if options.Subsumption > 0 {
+		cooccurrences = subsumingNodes(taxonomy, phrasesOfDocuments, options.Subsumption)
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 4: find the broader nodes of each node, by order of precedence of the relations
+	parents := make([][]TaxonomyEdge, len(taxonomy.Nodes))
+	for i, node := range taxonomy.Nodes {
+		linked := map[int]bool{}
+		link := func(j int, relation TaxonomyRelation, weight float64) {
+			if j < i && !linked[j] {
+				linked[j] = true
+				parents[i] = append(parents[i], TaxonomyEdge{j, i, relation, weight})
+			}
+		}
+		// (4.1) the longest phrase of its last words
+		words := strings.Split(node.Phrase, " ")
+		for k := 1; k < len(words); k++ {
+			if j, exists := taxonomy.index[strings.Join(words[k:], " ")]; exists {
+				link(j, RelationHead, 1.0)
+				break
+			}
+		}
+		// (4.2) the similar phrases
+		if options.Similarity != nil {
+			options.Similarity.ForEachNeighbor(node.Phrase, func(neighbor string, similarity float64) bool {
+				j, exists := taxonomy.index[neighbor]
+				if exists && similarity >= minSimilarity && taxonomy.Nodes[j].NumDocuments > node.NumDocuments {
+					link(j, RelationSimilarity, similarity)
+				}
+				return true
+			})
+		}
+		// (4.3) the phrases that occur in most of its documents, and in others
+		broader := make([]int, 0, len(cooccurrences[i]))
+		for j := range cooccurrences[i] {
+			broader = append(broader, j)
+		}
+		sort.Ints(broader)
+		for _, j := range broader {
+			count := cooccurrences[i][j]
+			fraction := float64(count) / float64(node.NumDocuments)
+			if count < taxonomy.Nodes[j].NumDocuments {
+				link(j, RelationSubsumption, fraction)
+			}
+		}
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 5: drop the links implied by longer paths, visiting the nodes in topological order
+	ancestors := make([]map[int]bool, len(taxonomy.Nodes))
+	for i, edges := range parents {
+		ancestors[i] = map[int]bool{}
+		for _, edge := range edges {
+			ancestors[i][edge.Broader] = true
+			for ancestor := range ancestors[edge.Broader] {
+				ancestors[i][ancestor] = true
+			}
+		}
+		for _, edge := range edges {
+			implied := false
+			for _, other := range edges {
+				if other.Broader != edge.Broader && ancestors[other.Broader][edge.Broader] {
+					implied = true
+					break
+				}
+			}
+			if !implied {
+				taxonomy.Edges = append(taxonomy.Edges, edge)
+				taxonomy.Nodes[i].Broader = append(taxonomy.Nodes[i].Broader, edge.Broader)
+				taxonomy.Nodes[edge.Broader].Narrower = append(taxonomy.Nodes[edge.Broader].Narrower, i)
+			}
+		}
+	}
+	return taxonomy
+}
+
+// =================================================================================================
+// method Taxonomy.Node
+// brief description:
+//   Get the node of a phrase.
+// input:
+//   phrase: the stemmed phrase
+// output:
+//   the index of the node, and false if the phrase is not in the taxonomy
+
+func (taxonomy *Taxonomy) Node(phrase string) (int, bool) {
+	i, exists := taxonomy.index[phrase]
+	return i, exists
+}
+
+// =================================================================================================
+// method Taxonomy.Roots
+// brief description:
+//   Get the nodes without broader nodes.
+// output:
+//   the indices of the roots, from the broadest
+
+func (taxonomy *Taxonomy) Roots() []int {
+	result := []int{}
+	for i, node := range taxonomy.Nodes {
+		if len(node.Broader) == 0 {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// taxonomyJSON is the layout of a taxonomy written by Taxonomy.WriteJSON.
+type taxonomyJSON struct {
+	Nodes []taxonomyNodeJSON `json:"nodes"`
+	Edges []taxonomyEdgeJSON `json:"edges"`
+}
+
+type taxonomyNodeJSON struct {
+	ID           int    `json:"id"`
+	Phrase       string `json:"phrase"`
+	Label        string `json:"label"`
+	NumDocuments int    `json:"documents"`
+}
+
+type taxonomyEdgeJSON struct {
+	Broader  int     `json:"broader"`
+	Narrower int     `json:"narrower"`
+	Relation string  `json:"relation"`
+	Weight   float64 `json:"weight"`
+}
+
+// =================================================================================================
+// method Taxonomy.WriteJSON
+// brief description:
+//   Write the taxonomy as a JSON object with its nodes and its edges, the edges referring to the
+//   nodes by their IDs.
+// input:
+//   writer: the writer
+// output:
+//   the error if any
+
+func (taxonomy *Taxonomy) WriteJSON(writer io.Writer) error {
+	layout := taxonomyJSON{
+		Nodes: make([]taxonomyNodeJSON, len(taxonomy.Nodes)),
+		Edges: make([]taxonomyEdgeJSON, len(taxonomy.Edges)),
+	}
+	for i, node := range taxonomy.Nodes {
+		layout.Nodes[i] = taxonomyNodeJSON{i, node.Phrase, node.Label, node.NumDocuments}
+	}
+	for i, edge := range taxonomy.Edges {
+		layout.Edges[i] = taxonomyEdgeJSON{edge.Broader, edge.Narrower, edge.Relation.String(), edge.Weight}
+	}
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(layout)
+}
+
+// =================================================================================================
+// method Taxonomy.WriteDOT
+// brief description:
+//   Write the taxonomy as a Graphviz DOT graph, with an arrow from each broader node to each
+//   narrower node.
+// input:
+//   writer: the writer
+// output:
+//   the error if any
+
+func (taxonomy *Taxonomy) WriteDOT(writer io.Writer) error {
+	buffered := bufio.NewWriter(writer)
+	fmt.Fprintln(buffered, "digraph taxonomy {")
+	fmt.Fprintln(buffered, "  rankdir=LR;")
+	for i, node := range taxonomy.Nodes {
+		fmt.Fprintf(buffered, "  n%d [label=%s];\n", i, dotString(node.Label))
+	}
+	for _, edge := range taxonomy.Edges {
+		fmt.Fprintf(buffered, "  n%d -> n%d [label=%s];\n", edge.Broader, edge.Narrower, dotString(edge.Relation.String()))
+	}
+	fmt.Fprintln(buffered, "}")
+	return buffered.Flush()
+}
+
+// =================================================================================================
+// function dotString
+// brief description:
+//   Quote a string for DOT.
+// input:
+//   text: the string
+// output:
+//   the quoted string
+
+func dotString(text string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(text) + `"`
+}
+
+// =================================================================================================
+// function turtleString
+// brief description:
+//   Quote a string for Turtle.
+// input:
+//   text: the string
+// output:
+//   the quoted string
+
+func turtleString(text string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`).Replace(text) + `"`
+}
+
+// =================================================================================================
+// function checkBaseIRI
+// brief description:
+//   Check that a base IRI is absolute and can be written in Turtle.
+// input:
+//   baseIRI: the base IRI
+// output:
+//   the error if the base IRI is not valid
+
+func checkBaseIRI(baseIRI string) error {
+	isControlOrSpace := func(r rune) bool { return r <= ' ' }
+	if strings.ContainsAny(baseIRI, "<>\"{}|^`\\") || strings.IndexFunc(baseIRI, isControlOrSpace) >= 0 {
+		return fmt.Errorf("KeyphraseExtraction: base IRI %q has characters that are not allowed in an IRI", baseIRI)
+	}
+	parsed, err := url.Parse(baseIRI)
+	if err != nil || !parsed.IsAbs() {
+		return fmt.Errorf("KeyphraseExtraction: base IRI %q is not an absolute IRI", baseIRI)
+	}
+	return nil
+}
+
+// =================================================================================================
+// method Taxonomy.WriteSKOS
+// brief description:
+//   Write the taxonomy as a SKOS concept scheme in Turtle.
+// input:
+//   writer: the writer
+//   baseIRI: the IRI that the IRIs of the scheme and of the concepts start with, e.g.
+//            "http://example.org/keyphrases/"
+//   language: the language tag of the labels, e.g. "en", or "" for none
+// output:
+//   the error if any, e.g. if the base IRI is not absolute
+// notes:
+//   The IRI of a concept is the base IRI followed by its stemmed phrase, escaped as a path segment.
+//   Each concept has its label as skos:prefLabel and its stemmed phrase as skos:hiddenLabel, so
+//   that it can be matched with the output of ExtractKeyPhraseCandidates.
+
+func (taxonomy *Taxonomy) WriteSKOS(writer io.Writer, baseIRI, language string) error {
+	if err := checkBaseIRI(baseIRI); err != nil {
+		return err
+	}
+	buffered := bufio.NewWriter(writer)
+	tag := ""
+	if language != "" {
+		tag = "@" + language
+	}
+	concept := func(i int) string {
+		return "<" + baseIRI + url.PathEscape(taxonomy.Nodes[i].Phrase) + ">"
+	}
+	fmt.Fprintln(buffered, "@prefix skos: <http://www.w3.org/2004/02/skos/core#> .")
+	fmt.Fprintln(buffered)
+	fmt.Fprintf(buffered, "<%s> a skos:ConceptScheme", baseIRI)
+	for _, i := range taxonomy.Roots() {
+		fmt.Fprintf(buffered, " ;\n  skos:hasTopConcept %s", concept(i))
+	}
+	fmt.Fprintln(buffered, " .")
+	for i, node := range taxonomy.Nodes {
+		fmt.Fprintln(buffered)
+		fmt.Fprintf(buffered, "%s a skos:Concept ;\n  skos:inScheme <%s> ;\n", concept(i), baseIRI)
+		fmt.Fprintf(buffered, "  skos:prefLabel %s%s ;\n  skos:hiddenLabel %s%s", turtleString(node.Label), tag,
+			turtleString(node.Phrase), tag)
+		if len(node.Broader) == 0 {
+			fmt.Fprintf(buffered, " ;\n  skos:topConceptOf <%s>", baseIRI)
+		}
+		for _, j := range node.Broader {
+			fmt.Fprintf(buffered, " ;\n  skos:broader %s", concept(j))
+		}
+		for _, j := range node.Narrower {
+			fmt.Fprintf(buffered, " ;\n  skos:narrower %s", concept(j))
+		}
+		fmt.Fprintln(buffered, " .")
+	}
+	return buffered.Flush()
+}
@@ -0,0 +1,186 @@
+package KeyphraseExtraction
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func newTestTaxonomyDocuments() []*Document {
+	documents := []*Document{}
+	for _, text := range []string{
+		"Convolutional neural networks classify images.",
+		"Convolutional neural networks, and recurrent neural networks.",
+		"Recurrent neural networks. Neural networks.",
+		"Neural networks learn. Deep learning.",
+		"Deep learning, and graph neural networks.",
+		"Graph neural networks. Deep learning.",
+	} {
+		documents = append(documents, ExtractDocument(text))
+	}
+	return documents
+}
+
+// reachable tells whether there is a path from a node to another, skipping an edge
+func reachable(taxonomy *Taxonomy, from, to int, skipped TaxonomyEdge) bool {
+	if from == to {
+		return true
+	}
+	for _, narrower := range taxonomy.Nodes[from].Narrower {
+		if from == skipped.Broader && narrower == skipped.Narrower {
+			continue
+		}
+		if reachable(taxonomy, narrower, to, skipped) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestInduceTaxonomy(t *testing.T) {
+	matrix := NewSimilarityMatrix()
+	documents := newTestTaxonomyDocuments()
+	stem := func(text string) string { return StemPhrases([]string{text})[0] }
+	// these phrases are similar and occur in as many documents, so that neither is broader
+	matrix.Set(stem("convolutional neural networks"), stem("recurrent neural networks"), 0.9)
+	matrix.Set(stem("deep learning"), stem("neural networks"), 0.9)
+	taxonomy := InduceTaxonomy(documents, TaxonomyOptions{Similarity: matrix, Subsumption: 0.6})
+	if len(taxonomy.Edges) == 0 {
+		t.Fatal("no edges")
+	}
+
+	// the edges go from the broadest nodes, so that the taxonomy is acyclic
+	for _, edge := range taxonomy.Edges {
+		if edge.Broader >= edge.Narrower {
+			t.Errorf("edge %+v goes to a broader node", edge)
+		}
+		broader, narrower := taxonomy.Nodes[edge.Broader], taxonomy.Nodes[edge.Narrower]
+		if broader.NumDocuments < narrower.NumDocuments {
+			t.Errorf("%q is broader than %q, which occurs in more documents", broader.Phrase, narrower.Phrase)
+		}
+		if edge.Relation == RelationSimilarity && broader.NumDocuments == narrower.NumDocuments {
+			t.Errorf("similar %q and %q occur in as many documents", broader.Phrase, narrower.Phrase)
+		}
+		// no edge is implied by a longer path
+		if reachable(taxonomy, edge.Broader, edge.Narrower, edge) {
+			t.Errorf("edge from %q to %q is implied by a longer path", broader.Phrase, narrower.Phrase)
+		}
+	}
+
+	// "convolutional neural network" is narrower than "neural network" through its head
+	i, exists := taxonomy.Node(stem("convolutional neural networks"))
+	j, _ := taxonomy.Node(stem("neural networks"))
+	if !exists || !reachable(taxonomy, j, i, TaxonomyEdge{Broader: -1}) {
+		t.Errorf("%q is not under %q", stem("convolutional neural networks"), stem("neural networks"))
+	}
+}
+
+func TestTaxonomyExportEscaping(t *testing.T) {
+	taxonomy := &Taxonomy{
+		Nodes: []TaxonomyNode{
+			{Phrase: "neural network", Label: `the "neural" network`, NumDocuments: 3, Narrower: []int{1}},
+			{Phrase: "neural_network", Label: "a\\b\nc", NumDocuments: 2, Broader: []int{0}},
+			{Phrase: "c/c++ <lang>", Label: "C/C++", NumDocuments: 2},
+		},
+		Edges: []TaxonomyEdge{{0, 1, RelationHead, 1.0}},
+	}
+
+	var skos bytes.Buffer
+	if err := taxonomy.WriteSKOS(&skos, "http://example.org/kp/", "en"); err != nil {
+		t.Fatal(err)
+	}
+	text := skos.String()
+	for _, want := range []string{
+		"<http://example.org/kp/neural%20network> a skos:Concept",
+		"<http://example.org/kp/neural_network> a skos:Concept",
+		"<http://example.org/kp/c%2Fc++%20%3Clang%3E> a skos:Concept",
+		`skos:prefLabel "the \"neural\" network"@en`,
+		`skos:prefLabel "a\\b\nc"@en`,
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("SKOS misses %s:\n%s", want, text)
+		}
+	}
+	for _, baseIRI := range []string{"", "keyphrases/", "http://example.org/a b/", "http://example.org/<kp>/"} {
+		if err := taxonomy.WriteSKOS(&bytes.Buffer{}, baseIRI, ""); err == nil {
+			t.Errorf("WriteSKOS accepted the base IRI %q", baseIRI)
+		}
+	}
+
+	var dot bytes.Buffer
+	if err := taxonomy.WriteDOT(&dot); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(dot.String(), `n0 [label="the \"neural\" network"];`) ||
+		!strings.Contains(dot.String(), `n1 [label="a\\b\nc"];`) {
+		t.Errorf("DOT labels are not escaped:\n%s", dot.String())
+	}
+
+	var encoded bytes.Buffer
+	if err := taxonomy.WriteJSON(&encoded); err != nil {
+		t.Fatal(err)
+	}
+	var decoded taxonomyJSON
+	if err := json.Unmarshal(encoded.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Nodes) != 3 || decoded.Nodes[0].Label != `the "neural" network` || len(decoded.Edges) != 1 {
+		t.Errorf("JSON round trip = %+v", decoded)
+	}
+}
+
+func TestSubsumingNodes(t *testing.T) {
+	// random documents of random phrases, whose nodes are sorted as by InduceTaxonomy
+	random := rand.New(rand.NewSource(1))
+	phrasesOfDocuments := make([]map[string]bool, 200)
+	documentFrequency := map[string]int{}
+	for d := range phrasesOfDocuments {
+		phrasesOfDocuments[d] = map[string]bool{}
+		for k := random.Intn(12); k >= 0; k-- {
+			phrase := fmt.Sprint("p", int(random.ExpFloat64()*8))
+			if !phrasesOfDocuments[d][phrase] {
+				phrasesOfDocuments[d][phrase] = true
+				documentFrequency[phrase]++
+			}
+		}
+	}
+	taxonomy := &Taxonomy{index: map[string]int{}}
+	for phrase, df := range documentFrequency {
+		taxonomy.Nodes = append(taxonomy.Nodes, TaxonomyNode{Phrase: phrase, NumDocuments: df})
+	}
+	sort.Slice(taxonomy.Nodes, func(i, j int) bool {
+		if taxonomy.Nodes[i].NumDocuments != taxonomy.Nodes[j].NumDocuments {
+			return taxonomy.Nodes[i].NumDocuments > taxonomy.Nodes[j].NumDocuments
+		}
+		return taxonomy.Nodes[i].Phrase < taxonomy.Nodes[j].Phrase
+	})
+	for i, node := range taxonomy.Nodes {
+		taxonomy.index[node.Phrase] = i
+	}
+
+	// the pruned counts are the counts of all the pairs that reach the fraction
+	for _, subsumption := range []float64{0.3, 0.6, 0.8, 1.0} {
+		got := subsumingNodes(taxonomy, phrasesOfDocuments, subsumption)
+		for i, node := range taxonomy.Nodes {
+			want := map[int]int{}
+			for j := 0; j < i; j++ {
+				count := 0
+				for _, phrases := range phrasesOfDocuments {
+					if phrases[node.Phrase] && phrases[taxonomy.Nodes[j].Phrase] {
+						count++
+					}
+				}
+				if float64(count)/float64(node.NumDocuments) >= subsumption {
+					want[j] = count
+				}
+			}
+			if fmt.Sprint(got[i]) != fmt.Sprint(want) {
+				t.Errorf("subsumption %v: counts of %q are %v, want %v", subsumption, node.Phrase, got[i], want)
+			}
+		}
+	}
+}
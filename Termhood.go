@@ -0,0 +1,196 @@
+package KeyphraseExtraction
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// NCValueOptions controls the context words of NCValue.
+type NCValueOptions struct {
+	// NumTopTerms is the number of top C-value terms from which the weights of the context words
+	// are learned. 0 means a tenth of the candidates, at least 1.
+	NumTopTerms int
+
+	// Window is the number of words before and after a candidate that are its context words, within
+	// its sentence. 0 means 1.
+	Window int
+}
+
+// =================================================================================================
+// function CValue
+// brief description:
+//   Compute the C-value of each key phrase candidate, which rewards long candidates and discounts
+//   the frequency that comes from being nested in longer candidates.
+// input:
+//   phraseCandidates: the key phrase candidates of a corpus, each occurrence once, e.g. the
+//                     concatenated outputs of ExtractKeyPhraseCandidates
+// output:
+//   the C-value of each distinct candidate
+// notes:
+//   A candidate a is nested in b if Includes(b, a). With f the frequency of a candidate, its nested
+//   occurrences included, and T(a) the candidates in which it is nested,
+//     C(a) = log2(|a| + 1) * f(a)                                   if T(a) is empty
+//     C(a) = log2(|a| + 1) * (f(a) - sum(f(b) for b in T(a)) / |T(a)|) otherwise,
+//   where |a| + 1 instead of |a| keeps the single words from scoring 0. The reference is:
+//   Frantzi, K., Ananiadou, S., & Mima, H. (2000). Automatic recognition of multi-word terms: the
+//   C-value/NC-value method. International Journal on Digital Libraries, 3(2), 115-130.
+
+func CValue(phraseCandidates []string) map[string]float64 {
+	// --------------------------------------------------------------------------------------------
+	// step 1: count the frequencies of the candidates, nested occurrences included
+	trie := NewPhraseTrie(nil)
+	for _, candidate := range phraseCandidates {
+		if candidate != "" {
+			trie.Insert(candidate)
+		}
+	}
+	for _, candidate := range phraseCandidates {
+		if candidate != "" {
+			trie.CountOccurrences(trie.Vocabulary().Encode(candidate))
+		}
+	}
+	frequency := map[string]float64{}
+	for candidate, count := range trie.Counts() {
+		frequency[candidate] = float64(count)
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 2: sum the frequencies of the candidates in which each candidate is nested
+	nestedFrequency := map[string]float64{}
+	numNesting := map[string]float64{}
+	for candidate, freq := range frequency {
+		for _, nested := range trie.ContainedIn(candidate) {
+			if nested != candidate {
+				nestedFrequency[nested] += freq
+				numNesting[nested] += 1.0
+			}
+		}
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 3: compute the C-values
+	result := make(map[string]float64, len(frequency))
+	for candidate, freq := range frequency {
+		lengthWeight := math.Log2(float64(strings.Count(candidate, " ") + 2))
+		if numNesting[candidate] > 0 {
+			freq -= nestedFrequency[candidate] / numNesting[candidate]
+		}
+		result[candidate] = lengthWeight * freq
+	}
+	return result
+}
+
+// =================================================================================================
+// function isContextWord
+// brief description:
+//   Check whether a token can be a context word of a candidate.
+// input:
+//   tok: the token
+// output:
+//   false for stop words, and for the tagged tokens that are not nouns, adjectives or verbs
+
+func isContextWord(tok Token) bool {
+	if stopWords[strings.ToLower(tok.Normalized)] {
+		return false
+	}
+	if tok.POS != "" {
+		return strings.HasPrefix(tok.POS, "NN") || strings.HasPrefix(tok.POS, "JJ") ||
+			strings.HasPrefix(tok.POS, "VB")
+	}
+	return true
+}
+
+// =================================================================================================
+// function NCValue
+// brief description:
+//   Compute the NC-value of each key phrase candidate, which adds to the C-value the weights of the
+//   context words of the candidate, learned from the top C-value candidates.
+// input:
+//   documents: the processed documents of a corpus
+//   options: the options of the context words
+// output:
+//   the NC-value of each distinct candidate
+// notes:
+//   With f_a(b) the number of times b is a context word of a, and t(b) the number of top terms of
+//   which b is a context word among n top terms,
+//     NC(a) = 0.8 * C(a) + 0.2 * sum(f_a(b) * t(b) / n for the context words b of a).
+//   The context words are stemmed. See CValue for the reference.
+
+func NCValue(documents []*Document, options NCValueOptions) map[string]float64 {
+	// --------------------------------------------------------------------------------------------
+	// step 1: compute the C-values
+	phraseCandidates := []string{}
+	for _, document := range documents {
+		phraseCandidates = append(phraseCandidates, document.CandidatePhrases()...)
+	}
+	cValue := CValue(phraseCandidates)
+
+	// --------------------------------------------------------------------------------------------
+	// step 2: count the context words of each candidate
+	window := options.Window
+	if window <= 0 {
+		window = 1
+	}
+	contexts := map[string]map[string]float64{}
+	for _, document := range documents {
+		for _, candidate := range document.Candidates {
+			sentence := document.Sentences[candidate.Sentence]
+			context := contexts[candidate.Phrase]
+			if context == nil {
+				context = map[string]float64{}
+				contexts[candidate.Phrase] = context
+			}
+			for i := candidate.Begin - window; i < candidate.End+window; i++ {
+				if i >= candidate.Begin && i < candidate.End {
+					continue
+				}
+				if i < sentence.Begin || i >= sentence.End || !isContextWord(document.Tokens[i]) {
+					continue
+				}
+				context[document.Tokens[i].Stem] += 1.0
+			}
+		}
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 3: learn the weights of the context words from the top terms
+	terms := make([]string, 0, len(cValue))
+	for term := range cValue {
+		terms = append(terms, term)
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if cValue[terms[i]] != cValue[terms[j]] {
+			return cValue[terms[i]] > cValue[terms[j]]
+		}
+		return terms[i] < terms[j]
+	})
+	numTopTerms := options.NumTopTerms
+	if numTopTerms <= 0 {
+		numTopTerms = len(terms) / 10
+		if numTopTerms < 1 {
+			numTopTerms = 1
+		}
+	}
+	if numTopTerms > len(terms) {
+		numTopTerms = len(terms)
+	}
+	weight := map[string]float64{}
+	for _, term := range terms[:numTopTerms] {
+		for word := range contexts[term] {
+			weight[word] += 1.0 / float64(numTopTerms)
+		}
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 4: compute the NC-values
+	result := make(map[string]float64, len(cValue))
+	for term, c := range cValue {
+		contextWeight := 0.0
+		for word, freq := range contexts[term] {
+			contextWeight += freq * weight[word]
+		}
+		result[term] = 0.8*c + 0.2*contextWeight
+	}
+	return result
+}
@@ -0,0 +1,80 @@
+package KeyphraseExtraction
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCValue(t *testing.T) {
+	// the example of Frantzi et al. (2000): "basal cell carcinoma" occurs 984 times, nested
+	// occurrences included, in 5 longer candidates whose frequencies, nested occurrences included,
+	// sum to 31
+	candidates := []string{}
+	for _, test := range []struct {
+		phrase string
+		count  int
+	}{
+		{"adenoid cystic basal cell carcinoma", 5},
+		{"cystic basal cell carcinoma", 11 - 5},
+		{"ulcerated basal cell carcinoma", 7},
+		{"recurrent basal cell carcinoma", 5},
+		{"circumscribed basal cell carcinoma", 3},
+		{"basal cell carcinoma", 984 - 5 - 6 - 7 - 5 - 3},
+	} {
+		for i := 0; i < test.count; i++ {
+			candidates = append(candidates, test.phrase)
+		}
+	}
+	cValue := CValue(candidates)
+
+	// log2(|a| + 1) instead of the log2(|a|) of the paper
+	want := map[string]float64{
+		"adenoid cystic basal cell carcinoma": math.Log2(6) * 5,
+		"cystic basal cell carcinoma":         math.Log2(5) * (11 - 5),
+		"ulcerated basal cell carcinoma":      math.Log2(5) * 7,
+		"recurrent basal cell carcinoma":      math.Log2(5) * 5,
+		"circumscribed basal cell carcinoma":  math.Log2(5) * 3,
+		"basal cell carcinoma":                math.Log2(4) * (984 - (5+11+7+5+3)/5.0),
+	}
+	if len(cValue) != len(want) {
+		t.Errorf("C-values of %d phrases, want %d", len(cValue), len(want))
+	}
+	for phrase, value := range want {
+		if math.Abs(cValue[phrase]-value) > 1e-9 {
+			t.Errorf("C-value of %q is %v, want %v", phrase, cValue[phrase], value)
+		}
+	}
+}
+
+func TestNCValue(t *testing.T) {
+	token := func(word string) Token { return Token{Normalized: word, Stem: word} }
+	documents := []*Document{
+		{
+			Sentences:  []Sentence{{Begin: 0, End: 3}},
+			Tokens:     []Token{token("patient"), token("alpha"), token("tumor")},
+			Candidates: []Candidate{{Phrase: "alpha", Begin: 1, End: 2}},
+		},
+		{
+			Sentences:  []Sentence{{Begin: 0, End: 3}},
+			Tokens:     []Token{token("patient"), token("alpha"), token("skin")},
+			Candidates: []Candidate{{Phrase: "alpha", Begin: 1, End: 2}},
+		},
+		{
+			// "tumor" is in the window of "beta", but not in its sentence
+			Sentences:  []Sentence{{Begin: 0, End: 1}, {Begin: 1, End: 3}},
+			Tokens:     []Token{token("tumor"), token("patient"), token("beta")},
+			Candidates: []Candidate{{Phrase: "beta", Sentence: 1, Begin: 2, End: 3}},
+		},
+	}
+	// C(alpha) = 2 and C(beta) = 1, and the top term alpha gives a weight of 1 to patient, tumor
+	// and skin, which occur 2, 1 and 1 times around alpha, and patient once around beta
+	ncValue := NCValue(documents, NCValueOptions{NumTopTerms: 1, Window: 2})
+	for phrase, value := range map[string]float64{
+		"alpha": 0.8*2 + 0.2*(2+1+1),
+		"beta":  0.8*1 + 0.2*1,
+	} {
+		if math.Abs(ncValue[phrase]-value) > 1e-9 {
+			t.Errorf("NC-value of %q is %v, want %v", phrase, ncValue[phrase], value)
+		}
+	}
+}
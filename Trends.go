@@ -0,0 +1,405 @@
+package KeyphraseExtraction
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// TrendMethod tells how the rise of the document frequency of a phrase is measured.
+type TrendMethod int
+
+const (
+	// TrendZScore compares the fraction of the documents of the recent buckets that have the phrase
+	// with its fraction in the baseline buckets, with a binomial z-score.
+	TrendZScore TrendMethod = iota
+
+	// TrendBurst finds the bursts of the phrase with the two-state automaton of Kleinberg, and
+	// weights the bursts in the recent buckets.
+	TrendBurst
+)
+
+// =================================================================================================
+// method TrendMethod.String
+// brief description:
+//   Get the name of a trend method.
+// output:
+//   "zscore" or "burst".
+
+func (method TrendMethod) String() string {
+	switch method {
+	case TrendZScore:
+		return "zscore"
+	case TrendBurst:
+		return "burst"
+	}
+	return fmt.Sprintf("TrendMethod(%d)", int(method))
+}
+
+// TimedDocument is the key phrase candidates of a document with the time of the document.
+type TimedDocument struct {
+	// Time is the time of the document, e.g. its publication.
+	Time time.Time
+
+	// Candidates are the key phrase candidates of the document, e.g. from ExtractKeyPhraseCandidates.
+	Candidates []string
+}
+
+// TrendOptions controls the detection of trending keyphrases.
+type TrendOptions struct {
+	// Method tells how the rise of the document frequency is measured.
+	Method TrendMethod
+
+	// Mode tells which n-grams are counted, as in IDFWithMode.
+	Mode CountingMode
+
+	// MaxNGramLength is the maximum number of words of the n-grams counted, as in IDFWithMode. 0
+	// means no limit.
+	MaxNGramLength int
+
+	// BucketSize is the duration of a time bucket. 0 means 24 hours.
+	BucketSize time.Duration
+
+	// MaxBuckets is the largest number of buckets that the documents may span. 0 means 10000.
+	MaxBuckets int
+
+	// RecentBuckets is the number of last buckets compared with the ones before. 0 means 1.
+	RecentBuckets int
+
+	// MinDocuments is the smallest number of documents of the recent buckets that a phrase must
+	// occur in. 0 means 1.
+	MinDocuments int
+
+	// TopK is the largest number of phrases returned. 0 means no limit.
+	TopK int
+
+	// BurstScale is how many times more frequent a phrase is in a burst, for TrendBurst. 0 means 2.
+	BurstScale float64
+
+	// BurstCost weights the cost of entering a burst, for TrendBurst. 0 means 1.
+	BurstCost float64
+}
+
+// TrendSeries is a trending keyphrase with its time series.
+type TrendSeries struct {
+	// Phrase is the keyphrase.
+	Phrase string
+
+	// Score measures the rise of the phrase in the recent buckets, higher is more trending.
+	Score float64
+
+	// DocumentFrequency is the number of documents that have the phrase in each bucket.
+	DocumentFrequency []uint
+
+	// Fraction is the fraction of the documents of each bucket that have the phrase.
+	Fraction []float64
+
+	// Burst tells whether each bucket is in a burst of the phrase, for TrendBurst, or is nil.
+	Burst []bool
+}
+
+// Trends is the trending keyphrases of timestamped documents.
+type Trends struct {
+	// Start is the time of the beginning of the first bucket.
+	Start time.Time
+
+	// BucketSize is the duration of a bucket.
+	BucketSize time.Duration
+
+	// NumDocuments is the number of documents of each bucket.
+	NumDocuments []uint
+
+	// Phrases are the trending keyphrases, by decreasing score.
+	Phrases []TrendSeries
+}
+
+// bucketCount is the number of documents of a bucket that have a phrase.
+type bucketCount struct {
+	bucket int
+	count  uint
+}
+
+// =================================================================================================
+// function bucketDocumentFrequencies
+// brief description:
+//   Count the document frequencies of the n-grams of timestamped documents in time buckets.
+// input:
+//   documents: the timestamped documents
+//   start: the beginning of the first bucket
+//   bucketSize: the duration of a bucket
+//   numBuckets: the number of buckets
+//   mode: which n-grams are counted
+//   maxNGramLength: the maximum number of words of an n-gram, or 0 for no limit
+// output:
+//   the sparse document frequencies of each phrase, only in the buckets where it occurs, and the
+//   number of documents of each bucket
+
+func bucketDocumentFrequencies(documents []TimedDocument, start time.Time, bucketSize time.Duration,
+	numBuckets int, mode CountingMode, maxNGramLength int) (map[string][]bucketCount, []uint) {
+	vocabulary := NewVocabulary()
+	texts := map[NGramKey]string{}
+	frequencies := map[NGramKey][]bucketCount{}
+	numDocuments := make([]uint, numBuckets)
+	groupResult := map[NGramKey][]WordID{}
+	for _, document := range documents {
+		bucket := int(document.Time.Sub(start) / bucketSize)
+		numDocuments[bucket]++
+		for key := range groupResult {
+			delete(groupResult, key)
+		}
+		countedNGramKeys(vocabulary, document.Candidates, mode, maxNGramLength, groupResult)
+		for key, ids := range groupResult {
+			counts, exists := frequencies[key]
+			if !exists {
+				texts[key] = vocabulary.Decode(ids)
+			}
+			// the documents are rarely out of order, so that a bucket mostly has one count
+			if last := len(counts) - 1; last >= 0 && counts[last].bucket == bucket {
+				counts[last].count++
+			} else {
+				counts = append(counts, bucketCount{bucket, 1})
+			}
+			frequencies[key] = counts
+		}
+	}
+	result := make(map[string][]bucketCount, len(frequencies))
+	for key, counts := range frequencies {
+		result[texts[key]] = counts
+	}
+	return result, numDocuments
+}
+
+// =================================================================================================
+// function zScore
+// brief description:
+//   Measure the rise of a phrase in the recent buckets with a binomial z-score.
+// input:
+//   frequencies: the document frequency of the phrase in each bucket
+//   numDocuments: the number of documents of each bucket
+//   numRecent: the number of recent buckets
+// output:
+//   the z-score of the fraction of the recent documents that have the phrase, against its
+//   fraction of the baseline documents, 0 without baseline or recent documents
+
+func zScore(frequencies []uint, numDocuments []uint, numRecent int) float64 {
+	baseline := len(frequencies) - numRecent
+	dfBaseline, nBaseline, dfRecent, nRecent := 0.0, 0.0, 0.0, 0.0
+	for t := range frequencies {
+		if t < baseline {
+			dfBaseline += float64(frequencies[t])
+			nBaseline += float64(numDocuments[t])
+		} else {
+			dfRecent += float64(frequencies[t])
+			nRecent += float64(numDocuments[t])
+		}
+	}
+	// without a baseline, nothing tells whether the phrase rises
+	if nBaseline == 0 || nRecent == 0 {
+		return 0.0
+	}
+	// the variance uses the fraction smoothed by Laplace, so that it is not 0
+	p0 := (dfBaseline + 1.0) / (nBaseline + 2.0)
+	return (dfRecent/nRecent - dfBaseline/nBaseline) / math.Sqrt(p0*(1.0-p0)/nRecent)
+}
+
+// =================================================================================================
+// function burstStates
+// brief description:
+//   Find the bursts of a phrase with the two-state automaton of Kleinberg.
+// input:
+//   frequencies: the document frequency of the phrase in each bucket
+//   numDocuments: the number of documents of each bucket
+//   scale: how many times more frequent the phrase is in a burst
+//   cost: the weight of the cost of entering a burst
+// output:
+//   whether each bucket is in a burst, and the weight of each bucket in a burst, which is how
+//   much cheaper the burst state explains it than the base state
+// notes:
+//   The reference is:
+//   Kleinberg, J. (2003). Bursty and hierarchical structure in streams. Data Mining and Knowledge
+//   Discovery, 7(4), 373-397.
+
+func burstStates(frequencies []uint, numDocuments []uint, scale, cost float64) ([]bool, []float64) {
+	// --------------------------------------------------------------------------------------------
+	// step 1: get the probabilities of the two states
+	numBuckets := len(frequencies)
+	bursts := make([]bool, numBuckets)
+	weights := make([]float64, numBuckets)
+	total, totalDocuments := 0.0, 0.0
+	for t := range frequencies {
+		total += float64(frequencies[t])
+		totalDocuments += float64(numDocuments[t])
+	}
+	if total == 0 || total == totalDocuments {
+		return bursts, weights
+	}
+	probabilities := [2]float64{total / totalDocuments, math.Min(scale*total/totalDocuments, 0.9999)}
+
+	// the cost of a bucket in a state, without the binomial coefficient that both states share
+	fit := func(state, t int) float64 {
+		r, d := float64(frequencies[t]), float64(numDocuments[t])
+		return -(r*math.Log(probabilities[state]) + (d-r)*math.Log(1.0-probabilities[state]))
+	}
+	transition := cost * math.Log(float64(numBuckets))
+
+	// --------------------------------------------------------------------------------------------
+	// step 2: find the cheapest sequence of states with the Viterbi algorithm
+	// the automaton starts in the base state
+	costs := [2]float64{0.0, math.Inf(1)}
+	previous := make([][2]int, numBuckets)
+	for t := 0; t < numBuckets; t++ {
+		var next [2]float64
+		for state := 0; state < 2; state++ {
+			// entering a burst costs transition, leaving it is free
+			fromBase, fromBurst := costs[0], costs[1]
+			if state == 1 {
+				fromBase += transition
+			}
+			if fromBase <= fromBurst {
+				next[state], previous[t][state] = fromBase, 0
+			} else {
+				next[state], previous[t][state] = fromBurst, 1
+			}
+			next[state] += fit(state, t)
+		}
+		costs = next
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 3: trace the states back
+	state := 0
+	if costs[1] < costs[0] {
+		state = 1
+	}
+	for t := numBuckets - 1; t >= 0; t-- {
+		if state == 1 {
+			bursts[t] = true
+			weights[t] = fit(0, t) - fit(1, t)
+		}
+		state = previous[t][state]
+	}
+	return bursts, weights
+}
+
+// =================================================================================================
+// function TrendingKeyphrases
+// brief description:
+//   Find the keyphrases whose document frequency rises in the recent time buckets of timestamped
+//   documents, compared with the buckets before.
+// input:
+//   documents: the timestamped documents
+//   options: the options of the detection
+// output:
+//   the time buckets, and the trending keyphrases with their time series, which can be charted,
+//   and an error if a document has no time or if the documents span more than MaxBuckets buckets
+
+func TrendingKeyphrases(documents []TimedDocument, options TrendOptions) (Trends, error) {
+	// --------------------------------------------------------------------------------------------
+	// step 1: divide the time into buckets
+	bucketSize := options.BucketSize
+	if bucketSize <= 0 {
+		bucketSize = 24 * time.Hour
+	}
+	maxBuckets := options.MaxBuckets
+	if maxBuckets <= 0 {
+		maxBuckets = 10000
+	}
+	result := Trends{BucketSize: bucketSize, Phrases: []TrendSeries{}}
+	if len(documents) == 0 {
+		return result, nil
+	}
+	first, last := documents[0].Time, documents[0].Time
+	for i, document := range documents {
+		if document.Time.IsZero() {
+			return Trends{}, fmt.Errorf("KeyphraseExtraction: document %d has no time", i)
+		}
+		if document.Time.Before(first) {
+			first = document.Time
+		}
+		if document.Time.After(last) {
+			last = document.Time
+		}
+	}
+	result.Start = first.Truncate(bucketSize)
+	// Sub saturates at about 292 years, which would merge the last buckets
+	span := last.Sub(result.Start)
+	if span == math.MaxInt64 || span/bucketSize >= time.Duration(maxBuckets) {
+		return Trends{}, fmt.Errorf("KeyphraseExtraction: the documents from %v to %v span more than %d buckets of %v",
+			first, last, maxBuckets, bucketSize)
+	}
+	numBuckets := int(span/bucketSize) + 1
+	numRecent := options.RecentBuckets
+	if numRecent <= 0 {
+		numRecent = 1
+	}
+	if numRecent > numBuckets {
+		numRecent = numBuckets
+	}
+	minDocuments := uint(options.MinDocuments)
+	if minDocuments == 0 {
+		minDocuments = 1
+	}
+	scale, cost := options.BurstScale, options.BurstCost
+	if scale <= 0 {
+		scale = 2.0
+	}
+	if cost <= 0 {
+		cost = 1.0
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 2: count the document frequencies in the buckets
+	frequencies, numDocuments := bucketDocumentFrequencies(documents, result.Start, bucketSize,
+		numBuckets, options.Mode, options.MaxNGramLength)
+	result.NumDocuments = numDocuments
+
+	// --------------------------------------------------------------------------------------------
+	// step 3: score the phrases that occur enough in the recent buckets
+	for phrase, counts := range frequencies {
+		dfRecent := uint(0)
+		for _, count := range counts {
+			if count.bucket >= numBuckets-numRecent {
+				dfRecent += count.count
+			}
+		}
+		if dfRecent < minDocuments {
+			continue
+		}
+		series := make([]uint, numBuckets)
+		for _, count := range counts {
+			series[count.bucket] += count.count
+		}
+		trend := TrendSeries{Phrase: phrase, DocumentFrequency: series, Fraction: make([]float64, numBuckets)}
+		for t, df := range series {
+			if numDocuments[t] > 0 {
+				trend.Fraction[t] = float64(df) / float64(numDocuments[t])
+			}
+		}
+		if options.Method == TrendBurst {
+			var weights []float64
+			trend.Burst, weights = burstStates(series, numDocuments, scale, cost)
+			for _, weight := range weights[numBuckets-numRecent:] {
+				trend.Score += weight
+			}
+		} else {
+			trend.Score = zScore(series, numDocuments, numRecent)
+		}
+		if trend.Score > 0 {
+			result.Phrases = append(result.Phrases, trend)
+		}
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 4: rank the phrases
+	sort.Slice(result.Phrases, func(i, j int) bool {
+		if result.Phrases[i].Score != result.Phrases[j].Score {
+			return result.Phrases[i].Score > result.Phrases[j].Score
+		}
+		return result.Phrases[i].Phrase < result.Phrases[j].Phrase
+	})
+	if options.TopK > 0 && len(result.Phrases) > options.TopK {
+		result.Phrases = result.Phrases[:options.TopK]
+	}
+	return result, nil
+}
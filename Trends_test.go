@@ -0,0 +1,88 @@
+package KeyphraseExtraction
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestTrendingKeyphrasesOneBucket(t *testing.T) {
+	// with a single bucket there is no baseline, so no phrase can rise
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	documents := []TimedDocument{
+		{start, []string{"neural network"}},
+		{start.Add(time.Hour), []string{"neural network", "graph"}},
+	}
+	trends, err := TrendingKeyphrases(documents, TrendOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trends.NumDocuments) != 1 {
+		t.Fatalf("got %d buckets, want 1", len(trends.NumDocuments))
+	}
+	if len(trends.Phrases) != 0 {
+		t.Errorf("got trending phrases %v without a baseline", trends.Phrases)
+	}
+}
+
+func TestTrendingKeyphrasesRise(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	documents := []TimedDocument{}
+	for day := 0; day < 5; day++ {
+		for i := 0; i < 10; i++ {
+			candidates := []string{"graph"}
+			if day == 4 && i < 8 || day < 4 && i == 0 {
+				candidates = append(candidates, "transformer")
+			}
+			documents = append(documents, TimedDocument{start.Add(time.Duration(day) * 24 * time.Hour), candidates})
+		}
+	}
+	for _, method := range []TrendMethod{TrendZScore, TrendBurst} {
+		trends, err := TrendingKeyphrases(documents, TrendOptions{Method: method})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(trends.Phrases) != 1 || trends.Phrases[0].Phrase != "transformer" {
+			t.Fatalf("%v: got %v, want only \"transformer\"", method, trends.Phrases)
+		}
+		if method == TrendZScore && trends.Phrases[0].Score < 3 {
+			t.Errorf("%v: got score %v for a rise from 10%% to 80%%", method, trends.Phrases[0].Score)
+		}
+	}
+}
+
+func TestTrendingKeyphrasesBuckets(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, test := range []struct {
+		name      string
+		documents []TimedDocument
+		options   TrendOptions
+	}{
+		{"zero time", []TimedDocument{{start, []string{"graph"}}, {time.Time{}, []string{"graph"}}}, TrendOptions{}},
+		{"tiny buckets", []TimedDocument{{start, []string{"graph"}}, {start.Add(time.Hour), []string{"graph"}}},
+			TrendOptions{BucketSize: time.Nanosecond}},
+		{"too many buckets", []TimedDocument{{start, []string{"graph"}}, {start.AddDate(0, 0, 100), []string{"graph"}}},
+			TrendOptions{MaxBuckets: 100}},
+		{"saturated span", []TimedDocument{{start, []string{"graph"}}, {start.AddDate(-400, 0, 0), []string{"graph"}}},
+			TrendOptions{BucketSize: 1000 * 24 * time.Hour}},
+	} {
+		if _, err := TrendingKeyphrases(test.documents, test.options); err == nil {
+			t.Errorf("%s: no error", test.name)
+		}
+	}
+
+	// documents out of order are counted in their buckets
+	documents := []TimedDocument{
+		{start.AddDate(0, 0, 2), []string{"graph"}},
+		{start, []string{"graph"}},
+		{start.AddDate(0, 0, 2), []string{"graph"}},
+		{start.AddDate(0, 0, 1), []string{"tree"}},
+	}
+	trends, err := TrendingKeyphrases(documents, TrendOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trends.Phrases) != 1 || fmt.Sprint(trends.Phrases[0].DocumentFrequency) != "[1 0 2]" {
+		t.Errorf("got %+v, want graph with [1 0 2]", trends.Phrases)
+	}
+}
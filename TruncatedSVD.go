@@ -0,0 +1,229 @@
+package KeyphraseExtraction
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// sparseRow is a row of a sparse matrix, with the values of its non-zero columns.
+type sparseRow struct {
+	columns []int
+	values  []float64
+}
+
+// =================================================================================================
+// function orthonormalize
+// brief description:
+//   Orthonormalize some vectors in place with the modified Gram-Schmidt process.
+// input:
+//   vectors: the vectors, of the same dimension. The vectors that depend on the previous ones
+//            become zero.
+
+func orthonormalize(vectors [][]float64) {
+	for k, vector := range vectors {
+		for _, previous := range vectors[:k] {
+			dot := 0.0
+			for i, value := range vector {
+				dot += value * previous[i]
+			}
+			for i := range vector {
+				vector[i] -= dot * previous[i]
+			}
+		}
+		norm := 0.0
+		for _, value := range vector {
+			norm += value * value
+		}
+		norm = math.Sqrt(norm)
+		for i := range vector {
+			if norm > 1e-12 {
+				vector[i] /= norm
+			} else {
+				vector[i] = 0.0
+			}
+		}
+	}
+}
+
+// =================================================================================================
+// function symmetricEigen
+// brief description:
+//   Compute the eigenvalues and the eigenvectors of a small symmetric matrix with the cyclic Jacobi
+//   method.
+// input:
+//   matrix: the symmetric matrix, which is overwritten
+// output:
+//   the eigenvalues, and the eigenvectors as the columns of a matrix
+
+func symmetricEigen(matrix [][]float64) ([]float64, [][]float64) {
+	n := len(matrix)
+	vectors := make([][]float64, n)
+	for i := range vectors {
+		vectors[i] = make([]float64, n)
+		vectors[i][i] = 1.0
+	}
+	for sweep := 0; sweep < 100; sweep++ {
+		offDiagonal := 0.0
+		for p := 0; p < n; p++ {
+			for q := p + 1; q < n; q++ {
+				offDiagonal += matrix[p][q] * matrix[p][q]
+			}
+		}
+		if offDiagonal < 1e-22 {
+			break
+		}
+		for p := 0; p < n; p++ {
+			for q := p + 1; q < n; q++ {
+				if math.Abs(matrix[p][q]) < 1e-300 {
+					continue
+				}
+				theta := (matrix[q][q] - matrix[p][p]) / (2.0 * matrix[p][q])
+				t := 1.0 / (math.Abs(theta) + math.Sqrt(theta*theta+1.0))
+				if theta < 0 {
+					t = -t
+				}
+				c := 1.0 / math.Sqrt(t*t+1.0)
+				s := t * c
+				for k := 0; k < n; k++ {
+					mkp, mkq := matrix[k][p], matrix[k][q]
+					matrix[k][p] = c*mkp - s*mkq
+					matrix[k][q] = s*mkp + c*mkq
+				}
+				for k := 0; k < n; k++ {
+					mpk, mqk := matrix[p][k], matrix[q][k]
+					matrix[p][k] = c*mpk - s*mqk
+					matrix[q][k] = s*mpk + c*mqk
+				}
+				for k := 0; k < n; k++ {
+					vkp, vkq := vectors[k][p], vectors[k][q]
+					vectors[k][p] = c*vkp - s*vkq
+					vectors[k][q] = s*vkp + c*vkq
+				}
+			}
+		}
+	}
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = matrix[i][i]
+	}
+	return values, vectors
+}
+
+// =================================================================================================
+// function truncatedSVD
+// brief description:
+//   Reduce the rows of a sparse matrix to their coordinates on its top singular vectors, with a
+//   randomized truncated SVD.
+// input:
+//   rows: the rows of the matrix
+//   numColumns: the number of columns of the matrix
+//   rank: the number of singular vectors kept
+//   seed: the seed of the random projection
+// output:
+//   the rows of U * S, where U holds the top left singular vectors and S the top singular values
+// notes:
+//   The reference is:
+//   Halko, N., Martinsson, P. G., & Tropp, J. A. (2011). Finding structure with randomness:
+//   Probabilistic algorithms for constructing approximate matrix decompositions. SIAM Review,
+//   53(2), 217-288.
+
+func truncatedSVD(rows []sparseRow, numColumns, rank int, seed int64) [][]float64 {
+	// --------------------------------------------------------------------------------------------
+	// step 1: size the random projection, with some oversampling
+	numRows := len(rows)
+	size := rank + 10
+	if size > numRows {
+		size = numRows
+	}
+	if size > numColumns {
+		size = numColumns
+	}
+	if rank > size {
+		rank = size
+	}
+
+	// the products with the matrix, on vectors stored as columns
+	multiply := func(columns [][]float64) [][]float64 {
+		result := make([][]float64, len(columns))
+		for k, column := range columns {
+			result[k] = make([]float64, numRows)
+			for i, row := range rows {
+				sum := 0.0
+				for idx, c := range row.columns {
+					sum += row.values[idx] * column[c]
+				}
+				result[k][i] = sum
+			}
+		}
+		return result
+	}
+	multiplyTransposed := func(columns [][]float64) [][]float64 {
+		result := make([][]float64, len(columns))
+		for k, column := range columns {
+			result[k] = make([]float64, numColumns)
+			for i, row := range rows {
+				for idx, c := range row.columns {
+					result[k][c] += row.values[idx] * column[i]
+				}
+			}
+		}
+		return result
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 2: find an orthonormal basis Q of the range of the matrix, with power iterations
+	random := rand.New(rand.NewSource(seed))
+	omega := make([][]float64, size)
+	for k := range omega {
+		omega[k] = make([]float64, numColumns)
+		for c := range omega[k] {
+			omega[k][c] = random.NormFloat64()
+		}
+	}
+	q := multiply(omega)
+	orthonormalize(q)
+	for iteration := 0; iteration < 2; iteration++ {
+		z := multiplyTransposed(q)
+		orthonormalize(z)
+		q = multiply(z)
+		orthonormalize(q)
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 3: decompose the small matrix B = Q^T A through the eigenvectors of B B^T
+	b := multiplyTransposed(q)
+	gram := make([][]float64, size)
+	for k1 := range gram {
+		gram[k1] = make([]float64, size)
+		for k2 := range gram[k1] {
+			for c, value := range b[k1] {
+				gram[k1][k2] += value * b[k2][c]
+			}
+		}
+	}
+	eigenvalues, eigenvectors := symmetricEigen(gram)
+	order := make([]int, size)
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return eigenvalues[order[i]] > eigenvalues[order[j]]
+	})
+
+	// --------------------------------------------------------------------------------------------
+	// step 4: compute the rows of U * S = Q * V * S
+	result := make([][]float64, numRows)
+	for i := range result {
+		result[i] = make([]float64, rank)
+		for r := 0; r < rank; r++ {
+			singularValue := math.Sqrt(math.Max(eigenvalues[order[r]], 0.0))
+			sum := 0.0
+			for k := 0; k < size; k++ {
+				sum += q[k][i] * eigenvectors[k][order[r]]
+			}
+			result[i][r] = sum * singularValue
+		}
+	}
+	return result
+}
@@ -0,0 +1,106 @@
+package KeyphraseExtraction
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestTruncatedSVDLowRank(t *testing.T) {
+	// a 30 x 20 matrix of rank 3, whose rows of U * S must give back A A^T = U S^2 U^T
+	random := rand.New(rand.NewSource(1))
+	numRows, numColumns, rank := 30, 20, 3
+	left := make([][]float64, numRows)
+	for i := range left {
+		left[i] = []float64{random.NormFloat64(), random.NormFloat64(), random.NormFloat64()}
+	}
+	right := make([][]float64, rank)
+	for r := range right {
+		right[r] = make([]float64, numColumns)
+		for c := range right[r] {
+			right[r][c] = random.NormFloat64()
+		}
+	}
+	dense := make([][]float64, numRows)
+	rows := make([]sparseRow, numRows)
+	for i := range dense {
+		dense[i] = make([]float64, numColumns)
+		for c := range dense[i] {
+			for r := 0; r < rank; r++ {
+				dense[i][c] += left[i][r] * right[r][c]
+			}
+			rows[i].columns = append(rows[i].columns, c)
+			rows[i].values = append(rows[i].values, dense[i][c])
+		}
+	}
+
+	reduced := truncatedSVD(rows, numColumns, rank, 1)
+	if len(reduced) != numRows || len(reduced[0]) != rank {
+		t.Fatalf("reduced to %d x %d instead of %d x %d", len(reduced), len(reduced[0]), numRows, rank)
+	}
+	for i := 0; i < numRows; i++ {
+		for j := 0; j < numRows; j++ {
+			expected, actual := 0.0, 0.0
+			for c := 0; c < numColumns; c++ {
+				expected += dense[i][c] * dense[j][c]
+			}
+			for r := 0; r < rank; r++ {
+				actual += reduced[i][r] * reduced[j][r]
+			}
+			if math.Abs(expected-actual) > 1e-6*(1+math.Abs(expected)) {
+				t.Fatalf("product of rows %d and %d is %v instead of %v", i, j, actual, expected)
+			}
+		}
+	}
+
+	// the singular values come first by size
+	norms := make([]float64, rank)
+	for _, row := range reduced {
+		for r, value := range row {
+			norms[r] += value * value
+		}
+	}
+	for r := 1; r < rank; r++ {
+		if norms[r] > norms[r-1]+1e-9 {
+			t.Errorf("singular value %d (%v) is larger than singular value %d (%v)", r, norms[r], r-1, norms[r-1])
+		}
+	}
+}
+
+func TestPPMIRows(t *testing.T) {
+	// total 8, phrases 3, 3 and 2, context words 4 and 4:
+	// PMI(a, x) = PMI(b, y) = log(2 * 8 / (3 * 4)), PMI(a, y) = PMI(b, x) = log(8 / 12) < 0 and
+	// PMI(c, x) = PMI(c, y) = log(8 / 8) = 0, so that c is left out
+	counts := map[string]map[string]float64{
+		"a": {"x": 2, "y": 1},
+		"b": {"x": 1, "y": 2},
+		"c": {"x": 1, "y": 1},
+	}
+	phrases, rows, numColumns := ppmiRows(counts)
+	if numColumns != 2 {
+		t.Fatalf("%d context words instead of 2", numColumns)
+	}
+	if len(phrases) != 2 {
+		t.Fatalf("phrases %v instead of a and b", phrases)
+	}
+	expected := math.Log(4.0 / 3.0)
+	columns := map[string]int{}
+	for i, phrase := range phrases {
+		if phrase == "c" {
+			t.Fatal("c has a positive PPMI")
+		}
+		if len(rows[i].columns) != 1 || math.Abs(rows[i].values[0]-expected) > 1e-12 {
+			t.Fatalf("row of %q is %v %v instead of one value %v", phrase, rows[i].columns, rows[i].values, expected)
+		}
+		columns[phrase] = rows[i].columns[0]
+	}
+	if columns["a"] == columns["b"] {
+		t.Errorf("a and b have the same context word %d", columns["a"])
+	}
+
+	// the two rows are orthogonal, so that they are not similar
+	similarity := sparseSimilarity(phrases, rows, 0.5, 0)
+	if len(similarity["a"]) != 1 || similarity["a"]["a"] != 1.0 {
+		t.Errorf("similarity of a is %v instead of itself only", similarity["a"])
+	}
+}
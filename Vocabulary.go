@@ -0,0 +1,457 @@
+package KeyphraseExtraction
+
+import (
+	"strings"
+	"sync"
+)
+
+// WordID is the integer ID of a stemmed word in a Vocabulary.
+type WordID uint32
+
+// NGramKey is the hashed key of a sequence of word IDs. The key of an n-gram is computed from the
+// key of its prefix, so that all the n-grams starting at a word are keyed without allocation. Keys
+// are not checked for collisions: two n-grams with the same key are counted as one, which is
+// expected once in about 2^32 distinct n-grams.
+type NGramKey uint64
+
+const nGramKeyBasis NGramKey = 14695981039346656037
+
+// Vocabulary interns stemmed words to integer IDs. It is safe for concurrent use.
+type Vocabulary struct {
+	mutex sync.RWMutex
+	ids   map[string]WordID
+	words []string
+}
+
+// =================================================================================================
+// function NewVocabulary
+// brief description:
+//   Create an empty vocabulary.
+// output:
+//   The new vocabulary.
+
+func NewVocabulary() *Vocabulary {
+	return &Vocabulary{ids: map[string]WordID{}}
+}
+
+// =================================================================================================
+// method Vocabulary.ID
+// brief description:
+//   Get the ID of a word, adding the word to the vocabulary if it is not there yet.
+// input:
+//   word: The word.
+// output:
+//   The ID of the word.
+
+func (vocabulary *Vocabulary) ID(word string) WordID {
+	vocabulary.mutex.RLock()
+	id, exists := vocabulary.ids[word]
+	vocabulary.mutex.RUnlock()
+	if exists {
+		return id
+	}
+
+	vocabulary.mutex.Lock()
+	defer vocabulary.mutex.Unlock()
+	id, exists = vocabulary.ids[word]
+	if !exists {
+		id = WordID(len(vocabulary.words))
+		vocabulary.ids[word] = id
+		vocabulary.words = append(vocabulary.words, word)
+	}
+	return id
+}
+
+// =================================================================================================
+// method Vocabulary.Lookup
+// brief description:
+//   Get the ID of a word without adding it to the vocabulary.
+// input:
+//   word: The word.
+// output:
+//   The ID of the word, and whether the word is in the vocabulary.
+
+func (vocabulary *Vocabulary) Lookup(word string) (WordID, bool) {
+	vocabulary.mutex.RLock()
+	defer vocabulary.mutex.RUnlock()
+	id, exists := vocabulary.ids[word]
+	return id, exists
+}
+
+// =================================================================================================
+// method Vocabulary.Word
+// brief description:
+//   Get the word of an ID.
+// input:
+//   id: The ID of the word.
+// output:
+//   The word.
+
+func (vocabulary *Vocabulary) Word(id WordID) string {
+	vocabulary.mutex.RLock()
+	defer vocabulary.mutex.RUnlock()
+	return vocabulary.words[id]
+}
+
+// =================================================================================================
+// method Vocabulary.Size
+// brief description:
+//   Get the number of words in the vocabulary.
+// output:
+//   The number of words.
+
+func (vocabulary *Vocabulary) Size() int {
+	vocabulary.mutex.RLock()
+	defer vocabulary.mutex.RUnlock()
+	return len(vocabulary.words)
+}
+
+// =================================================================================================
+// method Vocabulary.Encode
+// brief description:
+//   Convert a phrase to the IDs of its words, adding new words to the vocabulary.
+// input:
+//   phrase: The phrase, whose words are separated by single spaces.
+// output:
+//   The IDs of the words.
+
+func (vocabulary *Vocabulary) Encode(phrase string) []WordID {
+	return vocabulary.appendEncoded(make([]WordID, 0, strings.Count(phrase, " ")+1), phrase)
+}
+
+// =================================================================================================
+// method Vocabulary.appendEncoded
+// brief description:
+//   Append the IDs of the words of a phrase to a slice, adding new words to the vocabulary.
+// input:
+//   ids: The slice the IDs are appended to.
+//   phrase: The phrase, whose words are separated by single spaces.
+// output:
+//   The slice with the IDs of the words.
+
+func (vocabulary *Vocabulary) appendEncoded(ids []WordID, phrase string) []WordID {
+	// --------------------------------------------------------------------------------------------
+	// step 1: look the known words up under a single read lock
+	vocabulary.mutex.RLock()
+	for {
+		word, rest, found := strings.Cut(phrase, " ")
+		id, exists := vocabulary.ids[word]
+		if !exists {
+			break
+		}
+		ids = append(ids, id)
+		if !found {
+			vocabulary.mutex.RUnlock()
+			return ids
+		}
+		phrase = rest
+	}
+	vocabulary.mutex.RUnlock()
+
+	// --------------------------------------------------------------------------------------------
+	// step 2: add the words from the first unknown one
+	for {
+		word, rest, found := strings.Cut(phrase, " ")
+		ids = append(ids, vocabulary.ID(word))
+		if !found {
+			return ids
+		}
+		phrase = rest
+	}
+}
+
+// =================================================================================================
+// method Vocabulary.EncodeAll
+// brief description:
+//   Convert phrases to the IDs of their words, adding new words to the vocabulary.
+// input:
+//   phrases: The phrases.
+// output:
+//   The IDs of the words of each phrase, which share a single buffer.
+
+func (vocabulary *Vocabulary) EncodeAll(phrases []string) [][]WordID {
+	numWords := 0
+	for _, phrase := range phrases {
+		numWords += strings.Count(phrase, " ") + 1
+	}
+	buffer := make([]WordID, 0, numWords)
+	result := make([][]WordID, len(phrases))
+	for i, phrase := range phrases {
+		begin := len(buffer)
+		buffer = vocabulary.appendEncoded(buffer, phrase)
+		result[i] = buffer[begin:len(buffer):len(buffer)]
+	}
+	return result
+}
+
+// =================================================================================================
+// method Vocabulary.Decode
+// brief description:
+//   Convert word IDs back to a phrase.
+// input:
+//   ids: The IDs of the words.
+// output:
+//   The phrase, whose words are separated by single spaces.
+
+func (vocabulary *Vocabulary) Decode(ids []WordID) string {
+	vocabulary.mutex.RLock()
+	defer vocabulary.mutex.RUnlock()
+	var builder strings.Builder
+	for i, id := range ids {
+		if i > 0 {
+			builder.WriteByte(' ')
+		}
+		builder.WriteString(vocabulary.words[id])
+	}
+	return builder.String()
+}
+
+// =================================================================================================
+// method Vocabulary.Key
+// brief description:
+//   Get the n-gram key of a phrase without adding its words to the vocabulary.
+// input:
+//   phrase: The phrase, whose words are separated by single spaces.
+// output:
+//   The key of the phrase, and false if some of its words are not in the vocabulary.
+
+func (vocabulary *Vocabulary) Key(phrase string) (NGramKey, bool) {
+	vocabulary.mutex.RLock()
+	defer vocabulary.mutex.RUnlock()
+	key := nGramKeyBasis
+	for {
+		word, rest, found := strings.Cut(phrase, " ")
+		id, exists := vocabulary.ids[word]
+		if !exists {
+			return 0, false
+		}
+		key = ExtendNGramKey(key, id)
+		if !found {
+			return key, true
+		}
+		phrase = rest
+	}
+}
+
+// =================================================================================================
+// function ExtendNGramKey
+// brief description:
+//   Compute the key of an n-gram from the key of its prefix and the ID of its last word.
+// input:
+//   key: The key of the prefix, or the basis returned by NGramKeyOf(nil) for an empty prefix.
+//   id: The ID of the last word.
+// output:
+//   The key of the n-gram.
+
+func ExtendNGramKey(key NGramKey, id WordID) NGramKey {
+	key ^= NGramKey(id) + 1
+	key *= 1099511628211
+	key ^= key >> 29
+	return key
+}
+
+// =================================================================================================
+// function NGramKeyOf
+// brief description:
+//   Compute the key of a sequence of word IDs.
+// input:
+//   ids: The IDs of the words.
+// output:
+//   The key of the sequence.
+
+func NGramKeyOf(ids []WordID) NGramKey {
+	key := nGramKeyBasis
+	for _, id := range ids {
+		key = ExtendNGramKey(key, id)
+	}
+	return key
+}
+
+// =================================================================================================
+// function forEachNGram
+// brief description:
+//   Enumerate the n-grams of a phrase, no longer than a maximum.
+// input:
+//   ids: The IDs of the words of the phrase.
+//   maxNGramLength: The maximum number of words of an n-gram, or 0 for no limit.
+//   visit: The function called with the range [begin, end) and the key of each n-gram. It returns
+//          false to skip the longer n-grams starting at begin.
+
+func forEachNGram(ids []WordID, maxNGramLength int, visit func(begin, end int, key NGramKey) bool) {
+	numWords := len(ids)
+	for i := 0; i < numWords; i++ {
+		key := nGramKeyBasis
+		for j := i; j < nGramEnd(i, numWords, maxNGramLength); j++ {
+			key = ExtendNGramKey(key, ids[j])
+			if !visit(i, j+1, key) {
+				break
+			}
+		}
+	}
+}
+
+// =================================================================================================
+// method Vocabulary.nGramTexts
+// brief description:
+//   Build the texts of the n-grams of some phrases, each distinct n-gram once.
+// input:
+//   phrases: The IDs of the words of the phrases.
+//   maxNGramLength: The maximum number of words of an n-gram, or 0 for no limit.
+//   texts: The map that receives the texts of the n-grams by their keys.
+
+func (vocabulary *Vocabulary) nGramTexts(phrases [][]WordID, maxNGramLength int, texts map[NGramKey]string) {
+	for _, phrase := range phrases {
+		forEachNGram(phrase, maxNGramLength, func(begin, end int, key NGramKey) bool {
+			if _, exists := texts[key]; !exists {
+				texts[key] = vocabulary.Decode(phrase[begin:end])
+			}
+			return true
+		})
+	}
+}
+
+// =================================================================================================
+// function CountTF
+// brief description:
+//   Compute Term Frequencies for a set of key phrase candidates with a set of auxiliary phrases, on
+//   word IDs. See TF.
+// input:
+//   candidates: the word IDs of the key phrase candidates
+//   auxPhrases: the word IDs of the auxiliary phrases
+//   maxNGramLength: the maximum number of words of the n-grams counted, or 0 for no limit
+// output:
+//   The term frequency of each n-gram of the candidates, by its key
+
+func CountTF(candidates [][]WordID, auxPhrases [][]WordID, maxNGramLength int) map[NGramKey]uint {
+	// --------------------------------------------------------------------------------------------
+	// step 1: initialize the result
+	result := map[NGramKey]uint{}
+	for _, candidate := range candidates {
+		forEachNGram(candidate, maxNGramLength, func(begin, end int, key NGramKey) bool {
+			result[key] = 0
+			return true
+		})
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 2: scan through auxPhrases and compute the term frequencies
+	for _, auxPhrase := range auxPhrases {
+		stopped := false
+		forEachNGram(auxPhrase, maxNGramLength, func(begin, end int, key NGramKey) bool {
+			if stopped {
+				return false
+			}
+			oldFreq, exists := result[key]
+			if !exists {
+				// an unknown word stops the scan of this auxiliary phrase, as TF always did
+				stopped = end == begin+1
+				return false
+			}
+			result[key] = oldFreq + 1
+			return true
+		})
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 3: return the result
+	return result
+}
+
+// =================================================================================================
+// function IncludesIDs
+// brief description:
+//   Check whether a phrase includes another one, on word IDs. See Includes.
+// input:
+//   ids1: the word IDs of the including phrase
+//   ids2: the word IDs of the included phrase
+// output:
+//   true if ids2 is a contiguous part of ids1
+
+func IncludesIDs(ids1, ids2 []WordID) bool {
+	return includesSequence(ids1, ids2)
+}
+
+// =================================================================================================
+// function includesSequence
+// brief description:
+//   Check whether a sequence is a contiguous part of another one.
+// input:
+//   sequence1: the including sequence
+//   sequence2: the included sequence
+// output:
+//   true if sequence2 is a contiguous part of sequence1
+
+func includesSequence[T comparable](sequence1, sequence2 []T) bool {
+	n1 := len(sequence1)
+	n2 := len(sequence2)
+	for i := 0; i+n2 <= n1; i++ {
+		matched := true
+		for j := 0; j < n2; j++ {
+			if sequence1[i+j] != sequence2[j] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// =================================================================================================
+// function OverlapsIDs
+// brief description:
+//   Check whether two phrases overlap, on word IDs. See Overlaps.
+// input:
+//   ids1, ids2: the word IDs of the phrases
+// output:
+//   true if a prefix of one phrase is a suffix of the other
+
+func OverlapsIDs(ids1, ids2 []WordID) bool {
+	return overlapsSequence(ids1, ids2)
+}
+
+// =================================================================================================
+// function overlapsSequence
+// brief description:
+//   Check whether two sequences overlap.
+// input:
+//   sequence1, sequence2: the sequences
+// output:
+//   true if a prefix of one sequence is a suffix of the other
+
+func overlapsSequence[T comparable](sequence1, sequence2 []T) bool {
+	n1 := len(sequence1)
+	n2 := len(sequence2)
+	if n2 > n1 {
+		n1, n2 = n2, n1
+		sequence1, sequence2 = sequence2, sequence1
+	}
+	for k := 1; k <= n2; k++ {
+		// check if overlaps with sequence2 before sequence1
+		matched := true
+		for j := 0; j < k; j++ {
+			if sequence1[j] != sequence2[n2-k+j] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+
+		// check if overlaps with sequence1 before sequence2
+		matched = true
+		for j := 0; j < k; j++ {
+			if sequence1[n1-k+j] != sequence2[j] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package KeyphraseExtraction
+
+import (
+	"fmt"
+	"testing"
+)
+
+func benchmarkPhrases() []string {
+	phrases := make([]string, 1000)
+	for i := range phrases {
+		phrases[i] = fmt.Sprintf("deep word%d neural network word%d model", i%37, i%101)
+	}
+	return phrases
+}
+
+func BenchmarkVocabularyEncodeAll(b *testing.B) {
+	phrases := benchmarkPhrases()
+	vocabulary := NewVocabulary()
+	vocabulary.EncodeAll(phrases)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		vocabulary.EncodeAll(phrases)
+	}
+}
+
+func BenchmarkIncludes(b *testing.B) {
+	phrases := benchmarkPhrases()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, phrase := range phrases {
+			Includes(phrase, "neural network")
+			Overlaps(phrase, "model of deep")
+		}
+	}
+}
+
+func BenchmarkTF(b *testing.B) {
+	phrases := benchmarkPhrases()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		TF(phrases[:100], phrases)
+	}
+}
+
+func TestTextAdapters(t *testing.T) {
+	if got := GetAllPossiblePhrases("a b c"); fmt.Sprint(got) != "[a a b a b c b b c c]" {
+		t.Errorf("GetAllPossiblePhrases = %q", got)
+	}
+	for _, test := range []struct {
+		text1, text2       string
+		includes, overlaps bool
+	}{
+		{"deep neural network", "neural network", true, true},
+		{"neural network", "deep neural network", false, true},
+		{"network model", "deep neural network", false, true},
+		{"neural net", "neural network", false, false},
+		{"a b c d e f g h i j k l m n o p q r", "q r", true, true},
+	} {
+		if got := Includes(test.text1, test.text2); got != test.includes {
+			t.Errorf("Includes(%q, %q) = %v", test.text1, test.text2, got)
+		}
+		if got := Overlaps(test.text1, test.text2); got != test.overlaps {
+			t.Errorf("Overlaps(%q, %q) = %v", test.text1, test.text2, got)
+		}
+	}
+	allocs := testing.AllocsPerRun(100, func() {
+		Includes("deep neural network", "neural network")
+		Overlaps("deep neural network", "network model")
+	})
+	if allocs != 0 {
+		t.Errorf("Includes and Overlaps allocate %v times", allocs)
+	}
+}
+
+func TestMaxNGramLength(t *testing.T) {
+	candidates := []string{"a b c d"}
+	aux := []string{"a b c d", "b c"}
+	// the limits of concurrent calls do not interfere
+	results := make([]map[string]uint, 2)
+	done := make(chan bool)
+	for i, maxNGramLength := range []int{0, 2} {
+		go func(i, maxNGramLength int) {
+			results[i] = TFWithMode(candidates, aux, CountNested, maxNGramLength)
+			done <- true
+		}(i, maxNGramLength)
+	}
+	<-done
+	<-done
+	if len(results[0]) != 10 || results[0]["a b c d"] != 1 || results[0]["b c"] != 2 {
+		t.Errorf("TF without limit = %v", results[0])
+	}
+	if len(results[1]) != 7 || results[1]["b c"] != 2 {
+		t.Errorf("TF of bigrams = %v", results[1])
+	}
+	if idf := IDFWithMode([][]string{candidates}, CountExact, 3); len(idf) != 0 {
+		t.Errorf("IDF of a candidate longer than the limit = %v", idf)
+	}
+}
@@ -0,0 +1,277 @@
+package KeyphraseExtraction
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// The largest dimension and number of words accepted in the header of a word2vec file, so that a
+// corrupted header does not allocate without bound.
+const (
+	maxWord2VecDimension = 1 << 16
+	maxWord2VecWords     = 1 << 28
+)
+
+// WordVectors holds pre-trained word vectors, e.g. GloVe or word2vec, in the order of their file,
+// which is usually the order of decreasing frequency.
+type WordVectors struct {
+	dimension int
+	ranks     map[string]int
+	vectors   [][]float32
+}
+
+// =================================================================================================
+// function newWordVectors
+// brief description:
+//   Create an empty set of word vectors.
+// input:
+//   dimension: the dimension of the vectors, or 0 if it is not known yet
+// output:
+//   the new set
+
+func newWordVectors(dimension int) *WordVectors {
+	return &WordVectors{dimension: dimension, ranks: map[string]int{}}
+}
+
+// =================================================================================================
+// method WordVectors.add
+// brief description:
+//   Add the vector of a word, keeping the first vector of a word that appears twice.
+// input:
+//   word: the word
+//   vector: the vector
+
+func (vectors *WordVectors) add(word string, vector []float32) {
+	if _, exists := vectors.ranks[word]; exists {
+		return
+	}
+	vectors.ranks[word] = len(vectors.vectors)
+	vectors.vectors = append(vectors.vectors, vector)
+}
+
+// =================================================================================================
+// method WordVectors.Dimension
+// brief description:
+//   Get the dimension of the vectors.
+// output:
+//   the dimension
+
+func (vectors *WordVectors) Dimension() int {
+	return vectors.dimension
+}
+
+// =================================================================================================
+// method WordVectors.Len
+// brief description:
+//   Get the number of words.
+// output:
+//   the number of words
+
+func (vectors *WordVectors) Len() int {
+	return len(vectors.vectors)
+}
+
+// =================================================================================================
+// method WordVectors.Vector
+// brief description:
+//   Get the vector of a word, trying its lower case if the word itself is not there.
+// input:
+//   word: the word
+// output:
+//   the vector, which must not be modified, and false if the word is unknown
+
+func (vectors *WordVectors) Vector(word string) ([]float32, bool) {
+	rank, exists := vectors.Rank(word)
+	if !exists {
+		return nil, false
+	}
+	return vectors.vectors[rank], true
+}
+
+// =================================================================================================
+// method WordVectors.Rank
+// brief description:
+//   Get the rank of a word in the file, trying its lower case if the word itself is not there.
+// input:
+//   word: the word
+// output:
+//   the rank from 0, and false if the word is unknown
+
+func (vectors *WordVectors) Rank(word string) (int, bool) {
+	if rank, exists := vectors.ranks[word]; exists {
+		return rank, true
+	}
+	rank, exists := vectors.ranks[strings.ToLower(word)]
+	return rank, exists
+}
+
+// =================================================================================================
+// function ReadGloVe
+// brief description:
+//   Read word vectors in the text format of GloVe, one "word x1 x2 ... xd" line each. The header
+//   line of the text format of word2vec, "numWords dimension", is skipped.
+// input:
+//   reader: the reader
+// output:
+//   the word vectors, and the error if any
+
+func ReadGloVe(reader io.Reader) (*WordVectors, error) {
+	result := newWordVectors(0)
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 1<<16), 1<<26)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if lineNumber == 1 && len(fields) == 2 {
+			_, err1 := strconv.Atoi(fields[0])
+			_, err2 := strconv.Atoi(fields[1])
+			if err1 == nil && err2 == nil {
+				continue
+			}
+		}
+		if result.dimension == 0 {
+			result.dimension = len(fields) - 1
+		}
+		// some GloVe files have words with spaces, so the vector is taken from the end of the line
+		numWordFields := len(fields) - result.dimension
+		if numWordFields < 1 {
+			return nil, fmt.Errorf("KeyphraseExtraction: word vectors line %d: expected %d values",
+				lineNumber, result.dimension)
+		}
+		vector := make([]float32, result.dimension)
+		for i, field := range fields[numWordFields:] {
+			value, err := strconv.ParseFloat(field, 32)
+			if err != nil {
+				return nil, fmt.Errorf("KeyphraseExtraction: word vectors line %d: %v", lineNumber, err)
+			}
+			vector[i] = float32(value)
+		}
+		result.add(strings.Join(fields[:numWordFields], " "), vector)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// =================================================================================================
+// function ReadWord2Vec
+// brief description:
+//   Read word vectors in the binary format of word2vec: a "numWords dimension" header line, then
+//   for each word, the word, a space and dimension little-endian float32 values.
+// input:
+//   reader: the reader
+// output:
+//   the word vectors, and the error if any, e.g. if the dimension is larger than 65536 or the number
+//   of words larger than 2^28
+
+func ReadWord2Vec(reader io.Reader) (*WordVectors, error) {
+	// --------------------------------------------------------------------------------------------
+	// step 1: read the header
+	buffered := bufio.NewReader(reader)
+	header, err := buffered.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("KeyphraseExtraction: word2vec header: %v", err)
+	}
+	var numWords, dimension int
+	if _, err := fmt.Sscanf(strings.TrimSpace(header), "%d %d", &numWords, &dimension); err != nil ||
+		numWords < 0 || dimension <= 0 {
+		return nil, fmt.Errorf("KeyphraseExtraction: word2vec header: %q", strings.TrimSpace(header))
+	}
+	if dimension > maxWord2VecDimension {
+		return nil, fmt.Errorf("KeyphraseExtraction: word2vec dimension %d is larger than %d", dimension,
+			maxWord2VecDimension)
+	}
+	if numWords > maxWord2VecWords {
+		return nil, fmt.Errorf("KeyphraseExtraction: word2vec number of words %d is larger than %d", numWords,
+			maxWord2VecWords)
+	}
+
+	// --------------------------------------------------------------------------------------------
+	// step 2: read the words and their vectors
+	result := newWordVectors(dimension)
+	buffer := make([]byte, 4*dimension)
+	for i := 0; i < numWords; i++ {
+		word, err := buffered.ReadString(' ')
+		if err != nil {
+			return nil, fmt.Errorf("KeyphraseExtraction: word2vec word %d: %v", i, err)
+		}
+		word = strings.TrimLeft(word[:len(word)-1], "\n")
+		if _, err := io.ReadFull(buffered, buffer); err != nil {
+			return nil, fmt.Errorf("KeyphraseExtraction: word2vec word %d: %v", i, err)
+		}
+		vector := make([]float32, dimension)
+		for j := range vector {
+			vector[j] = math.Float32frombits(binary.LittleEndian.Uint32(buffer[4*j:]))
+		}
+		result.add(word, vector)
+	}
+	return result, nil
+}
+
+// =================================================================================================
+// function LoadWordVectors
+// brief description:
+//   Load word vectors from a local file, in the binary format of word2vec if its name ends with
+//   ".bin", or in the text format of GloVe otherwise.
+// input:
+//   path: the path of the file
+// output:
+//   the word vectors, and the error if any
+
+func LoadWordVectors(path string) (*WordVectors, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	if strings.HasSuffix(path, ".bin") {
+		return ReadWord2Vec(file)
+	}
+	return ReadGloVe(file)
+}
+
+// =================================================================================================
+// method WordVectors.zipfProbability
+// brief description:
+//   Estimate the probability of a word from its rank in the file with Zipf's law.
+// input:
+//   rank: the rank of the word from 0
+// output:
+//   the estimated probability
+
+func (vectors *WordVectors) zipfProbability(rank int) float64 {
+	// the harmonic number of the size of the vocabulary
+	harmonic := math.Log(float64(len(vectors.vectors))) + 0.5772156649
+	return 1.0 / (float64(rank+1) * harmonic)
+}
+
+// =================================================================================================
+// function cosine
+// brief description:
+//   Compute the cosine similarity between two vectors.
+// input:
+//   a, b: the vectors, of the same dimension
+// output:
+//   the cosine similarity, or 0 if one of the vectors is zero
+
+func cosine(a, b []float64) float64 {
+	dot, normA, normB := 0.0, 0.0, 0.0
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0.0
+	}
+	return dot / math.Sqrt(normA*normB)
+}
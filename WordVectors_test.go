@@ -0,0 +1,91 @@
+package KeyphraseExtraction
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+func word2VecFile(header string, words []string, vectors [][]float32) []byte {
+	var buffer bytes.Buffer
+	buffer.WriteString(header + "\n")
+	for i, word := range words {
+		buffer.WriteString(word + " ")
+		for _, value := range vectors[i] {
+			binary.Write(&buffer, binary.LittleEndian, math.Float32bits(value))
+		}
+		buffer.WriteString("\n")
+	}
+	return buffer.Bytes()
+}
+
+func TestReadWord2Vec(t *testing.T) {
+	words := []string{"the", "graph", "Graph"}
+	vectors := [][]float32{{0.5, -1}, {1, 2}, {3, 4}}
+	result, err := ReadWord2Vec(bytes.NewReader(word2VecFile("3 2", words, vectors)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Len() != 3 || result.Dimension() != 2 {
+		t.Fatalf("%d words of dimension %d instead of 3 of dimension 2", result.Len(), result.Dimension())
+	}
+	for i, word := range words {
+		vector, exists := result.Vector(word)
+		if !exists || vector[0] != vectors[i][0] || vector[1] != vectors[i][1] {
+			t.Errorf("vector of %q is %v instead of %v", word, vector, vectors[i])
+		}
+	}
+	// an unknown word falls back to its lower case
+	if rank, exists := result.Rank("THE"); !exists || rank != 0 {
+		t.Errorf("rank of THE is %d, %v instead of 0", rank, exists)
+	}
+
+	for _, test := range []struct {
+		header string
+		err    string
+	}{
+		{"3", "header"},
+		{"3 0", "header"},
+		{"-1 2", "header"},
+		{fmt.Sprintf("3 %d", maxWord2VecDimension+1), "dimension"},
+		{fmt.Sprintf("%d 2", maxWord2VecWords+1), "number of words"},
+		{"4 2", "word 3"},
+	} {
+		_, err := ReadWord2Vec(bytes.NewReader(word2VecFile(test.header, words, vectors)))
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("header %q: error %v instead of one about %s", test.header, err, test.err)
+		}
+	}
+	// a truncated vector
+	file := word2VecFile("3 2", words, vectors)
+	if _, err := ReadWord2Vec(bytes.NewReader(file[:len(file)-3])); err == nil {
+		t.Error("no error on a truncated file")
+	}
+}
+
+func TestReadGloVe(t *testing.T) {
+	text := "3 2\nthe 0.5 -1\n\nnew york 1 2\nthe 7 7\n"
+	result, err := ReadGloVe(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Len() != 2 || result.Dimension() != 2 {
+		t.Fatalf("%d words of dimension %d instead of 2 of dimension 2", result.Len(), result.Dimension())
+	}
+	// the first vector of a word is kept, and a word can have spaces
+	if vector, _ := result.Vector("the"); vector[0] != 0.5 || vector[1] != -1 {
+		t.Errorf("vector of the is %v", vector)
+	}
+	if vector, exists := result.Vector("new york"); !exists || vector[0] != 1 || vector[1] != 2 {
+		t.Errorf("vector of new york is %v, %v", vector, exists)
+	}
+
+	for _, text := range []string{"a 1 2\nb 3\n", "a 1 2\nb 3 x\n"} {
+		if _, err := ReadGloVe(strings.NewReader(text)); err == nil || !strings.Contains(err.Error(), "line 2") {
+			t.Errorf("%q: error %v instead of one on line 2", text, err)
+		}
+	}
+}